@@ -0,0 +1,171 @@
+package wallet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number with 2 decimals, such as the value of an [Amount], held as an integer
+// number of hundredths so that sums do not accumulate floating point errors. It is encoded in JSON as a number
+// with 2 decimals, for instance, 1000.50.
+type Decimal struct {
+	hundredths int64
+}
+
+// NewDecimal returns value rounded half away from zero to 2 decimals, from its shortest decimal representation
+// so that, for instance, 1.005 is 1.01. NaN and infinite values are zero.
+func NewDecimal(value float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(value, 'f', -1, 64))
+	if err != nil {
+		return Decimal{}
+	}
+	return d
+}
+
+// ParseDecimal parses s, an optionally signed decimal number without exponent such as "1000.50", rounded half away
+// from zero to 2 decimals.
+//
+// Errors:
+//   - [ErrInvalidParameter] when s is not a decimal number or has more than 16 integer digits.
+func ParseDecimal(s string) (Decimal, error) {
+	digits := s
+	negative := strings.HasPrefix(digits, "-")
+	if negative || strings.HasPrefix(digits, "+") {
+		digits = digits[1:]
+	}
+	integer, fraction, _ := strings.Cut(digits, ".")
+	if integer == "" || len(integer) > 16 || !isDigits(integer) || !isDigits(fraction) {
+		return Decimal{}, Error{Code: ErrInvalidParameter, Message: "wallet: invalid decimal " + strconv.Quote(s) + "."}
+	}
+	units, _ := strconv.ParseInt(integer, 10, 64)
+	cents, _ := strconv.ParseInt((fraction + "00")[:2], 10, 64)
+	hundredths := units*100 + cents
+	if len(fraction) > 2 && fraction[2] >= '5' {
+		hundredths++
+	}
+	if negative {
+		hundredths = -hundredths
+	}
+	return Decimal{hundredths: hundredths}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Float64 returns d as a float64, for computations that do not need to be exact such as percentages.
+func (d Decimal) Float64() float64 {
+	return float64(d.hundredths) / 100
+}
+
+// Sign returns -1, 0 or 1 whether d is negative, zero or positive.
+func (d Decimal) Sign() int {
+	switch {
+	case d.hundredths < 0:
+		return -1
+	case d.hundredths > 0:
+		return 1
+	}
+	return 0
+}
+
+// String returns d formatted with 2 decimals, for instance, "1000.50".
+func (d Decimal) String() string {
+	hundredths, sign := d.hundredths, ""
+	if hundredths < 0 {
+		hundredths, sign = -hundredths, "-"
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, hundredths/100, hundredths%100)
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. Numbers with an exponent are parsed as a float64 first.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "null" {
+		return nil
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		value, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return err
+		}
+		parsed = NewDecimal(value)
+	}
+	*d = parsed
+	return nil
+}
+
+// Amount is a monetary amount in a currency, for instance, Amount{Value: NewDecimal(1000), Currency: "MYR"}.
+// It is encoded in JSON as {"amount": 1000.00, "currency": "MYR"}, the shape of the amounts of the command
+// inputs, such as [CreateInvestmentRequestInput.Amount].
+//
+// Arithmetic between amounts in different currencies fails with [ErrCurrencyMismatch] rather than
+// mixing them, see [FxRates.ConvertAmount] to convert an amount first. Amounts are also returned by the
+// accessors of the outputs pairing an amount with its currency, such as [Balance.ValueAmount].
+type Amount struct {
+	Value    Decimal `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// NewAmount returns the amount of value in currency, rounded half away from zero to 2 decimals, see [NewDecimal].
+func NewAmount(value float64, currency string) Amount {
+	return Amount{Value: NewDecimal(value), Currency: currency}
+}
+
+// ParseAmount returns the amount of value in currency, value being parsed with [ParseDecimal].
+//
+// Errors:
+//   - [ErrInvalidParameter] when value is not a decimal number.
+func ParseAmount(value string, currency string) (Amount, error) {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{Value: d, Currency: currency}, nil
+}
+
+// Add returns the exact sum of a and b. It returns [ErrCurrencyMismatch] when they are in different currencies.
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, currencyMismatch("add", a, b)
+	}
+	return Amount{Value: Decimal{hundredths: a.Value.hundredths + b.Value.hundredths}, Currency: a.Currency}, nil
+}
+
+// Sub returns a minus b, exactly. It returns [ErrCurrencyMismatch] when they are in different currencies.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, currencyMismatch("subtract", a, b)
+	}
+	return Amount{Value: Decimal{hundredths: a.Value.hundredths - b.Value.hundredths}, Currency: a.Currency}, nil
+}
+
+// String returns the amount formatted with 2 decimals followed by its currency, for instance, "1000.00 MYR".
+func (a Amount) String() string {
+	return a.Value.String() + " " + a.Currency
+}
+
+func currencyMismatch(op string, a Amount, b Amount) error {
+	return Error{Code: ErrCurrencyMismatch, Message: fmt.Sprintf("wallet: cannot %s %s and %s.", op, a, b)}
+}
+
+// ConvertAmount converts amount to the currency to, as [FxRates.Convert] does.
+func (r FxRates) ConvertAmount(amount Amount, to string) (Amount, error) {
+	value, err := r.Convert(amount.Value.Float64(), amount.Currency, to)
+	if err != nil {
+		return Amount{}, err
+	}
+	return NewAmount(value, to), nil
+}
@@ -0,0 +1,142 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecimal(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want string
+	}{
+		{"1000", "1000.00"},
+		{"1000.5", "1000.50"},
+		{"0.125", "0.13"},
+		{"-0.125", "-0.13"},
+		{"+12.344", "12.34"},
+	} {
+		d, err := ParseDecimal(tt.in)
+		if err != nil || d.String() != tt.want {
+			t.Errorf("%q: got %s, %v, want %s", tt.in, d, err, tt.want)
+		}
+	}
+	var werr Error
+	for _, in := range []string{"", ".5", "1e3", "1.2.3", "12345678901234567"} {
+		if _, err := ParseDecimal(in); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+			t.Errorf("%q: got %v, want %s", in, err, ErrInvalidParameter)
+		}
+	}
+
+	// the shortest representation of the float is rounded, not its binary value.
+	if d := NewDecimal(1.005); d.String() != "1.01" {
+		t.Errorf("got %s, want 1.01", d)
+	}
+	// sums are exact.
+	sum := Amount{Currency: "MYR"}
+	for i := 0; i < 10; i++ {
+		sum, _ = sum.Add(NewAmount(0.1, "MYR"))
+	}
+	if sum != NewAmount(1, "MYR") {
+		t.Errorf("got %s, want 1.00 MYR", sum)
+	}
+
+	var decoded struct{ A, B Decimal }
+	if err := json.Unmarshal([]byte(`{"A": 1000.505, "B": 1.5e2}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.A.String() != "1000.51" || decoded.B.String() != "150.00" {
+		t.Errorf("got %s and %s, want 1000.51 and 150.00", decoded.A, decoded.B)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	a := NewAmount(1000.125, "MYR")
+	if a.Value.String() != "1000.13" || a.Currency != "MYR" || a.String() != "1000.13 MYR" {
+		t.Errorf("got %+v (%s)", a, a)
+	}
+
+	b, err := json.Marshal(NewAmount(212.5, "USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":212.50,"currency":"USD"}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+	var decoded Amount
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != NewAmount(212.5, "USD") {
+		t.Errorf("got %+v", decoded)
+	}
+	if parsed, err := ParseAmount("212.50", "USD"); err != nil || parsed != decoded {
+		t.Errorf("got %v, %v, want 212.50 USD", parsed, err)
+	}
+
+	sum, err := a.Add(NewAmount(0.2, "MYR"))
+	if err != nil || sum != NewAmount(1000.33, "MYR") {
+		t.Errorf("got %v, %v, want 1000.33 MYR", sum, err)
+	}
+	diff, err := a.Sub(NewAmount(0.13, "MYR"))
+	if err != nil || diff != NewAmount(1000, "MYR") {
+		t.Errorf("got %v, %v, want 1000.00 MYR", diff, err)
+	}
+
+	var werr Error
+	if _, err := a.Add(NewAmount(1, "USD")); !errors.As(err, &werr) || werr.Code != ErrCurrencyMismatch {
+		t.Errorf("got %v, want %s", err, ErrCurrencyMismatch)
+	}
+	if _, err := a.Sub(NewAmount(1, "USD")); !errors.As(err, &werr) || werr.Code != ErrCurrencyMismatch {
+		t.Errorf("got %v, want %s", err, ErrCurrencyMismatch)
+	}
+
+	usd, err := FxRates{"MYR": 1, "USD": 0.2125}.ConvertAmount(NewAmount(1000, "MYR"), "USD")
+	if err != nil || usd != NewAmount(212.5, "USD") {
+		t.Errorf("got %v, %v, want 212.50 USD", usd, err)
+	}
+}
+
+func TestAmountCommandInputs(t *testing.T) {
+	var payloads []string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payloads = append(payloads, string(decodeTestRequest(t, req).Payload))
+		return jsonResponse(http.StatusOK, map[string]any{"requestId": "req_1"}), nil
+	})
+	ctx := context.Background()
+
+	invest := &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1", FundClassSequence: 2, Amount: NewAmount(1000.1, "USD")}
+	if _, err := c.CreateInvestmentRequest(ctx, invest); err != nil {
+		t.Fatal(err)
+	}
+	redeem := &CreateRedemptionRequestInput{AccountID: "acc_1", FundID: "fund_1", Units: 10}
+	if _, err := c.CreateRedemptionRequest(ctx, redeem); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(payloads[0], `"amount":{"amount":1000.10,"currency":"USD"}`) {
+		t.Errorf("got payload %s, want the amount and its currency", payloads[0])
+	}
+	// a redemption by units has no amount.
+	if strings.Contains(payloads[1], "requestedAmount") {
+		t.Errorf("got payload %s, want no requested amount", payloads[1])
+	}
+
+	var werr Error
+	_, err := c.CreateInvestmentRequest(ctx, &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1", Amount: Amount{Value: NewDecimal(1000)}})
+	if !errors.As(err, &werr) || werr.Code != ErrMissingParameter {
+		t.Errorf("got %v, want %s for an amount without currency", err, ErrMissingParameter)
+	}
+
+	balances := &ListClientAccountBalanceOutput{Balance: []*Balance{{Asset: "MYR", Value: 100.25}, {Asset: "MYR", Value: 50.5}}}
+	if total, err := balances.TotalValue(); err != nil || total != NewAmount(150.75, "MYR") {
+		t.Errorf("got %v, %v, want 150.75 MYR", total, err)
+	}
+	balances.Balance = append(balances.Balance, &Balance{Asset: "USD", Value: 10})
+	if _, err := balances.TotalValue(); !errors.As(err, &werr) || werr.Code != ErrCurrencyMismatch {
+		t.Errorf("got %v, want %s", err, ErrCurrencyMismatch)
+	}
+}
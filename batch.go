@@ -0,0 +1,66 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// batchConcurrency bounds the requests sent concurrently by the helpers fetching several resources,
+// such as [Client.GetProjectedFundPrices] and [Client.DownloadConfirmations].
+const batchConcurrency = 4
+
+// BatchResult represents the outcome of a helper fetching several resources, such as
+// [Client.GetProjectedFundPrices], where some of them may succeed while others fail.
+type BatchResult[T any] struct {
+	// Succeeded specifies the results of the items which succeeded, by key.
+	Succeeded map[string]T
+
+	// Failed specifies the errors of the items which failed, by key.
+	Failed map[string]error
+}
+
+// Err returns the errors of the items joined, each prefixed with its key, when every item failed.
+// It returns nil on partial success, which is represented by Failed.
+func (r *BatchResult[T]) Err() error {
+	if len(r.Failed) == 0 || len(r.Succeeded) > 0 {
+		return nil
+	}
+	errs := make([]error, 0, len(r.Failed))
+	for _, key := range slices.Sorted(maps.Keys(r.Failed)) {
+		errs = append(errs, fmt.Errorf("%s: %w", key, r.Failed[key]))
+	}
+	return errors.Join(errs...)
+}
+
+// runBatch calls fetch for the items of keys, up to batchConcurrency concurrently, and collects their
+// results by key. A key appearing several times keeps the result of one of its items.
+func runBatch[T any](keys []string, fetch func(i int) (T, error)) *BatchResult[T] {
+	result := &BatchResult[T]{
+		Succeeded: make(map[string]T, len(keys)),
+		Failed:    map[string]error{},
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, batchConcurrency)
+	for i, key := range keys {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			output, err := fetch(i)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed[key] = err
+				return
+			}
+			result.Succeeded[key] = output
+		}()
+	}
+	wg.Wait()
+	return result
+}
@@ -0,0 +1,104 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// verifyBodyHash returns a middleware that checks the SHA-256 of the body actually sent matches
+// the bodyHash claim of the request token, logging a warning with logger on mismatch. The body is
+// hashed as it is sent rather than buffered, so large bodies are not held in memory, and only its
+// hashes and size are logged. The request is sent either way as the server remains the authority
+// on the signature.
+func verifyBodyHash(logger *slog.Logger) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			claim, err := bodyHashClaim(req.Header.Get("Authorization"))
+			if err != nil {
+				logger.Warn("wallet: cannot verify the body hash of the request",
+					slog.String("path", req.URL.Path),
+					slog.String("error", err.Error()),
+				)
+				return next.RoundTrip(req)
+			}
+			body := &hashingBody{hash: sha256.New(), report: func(actual string, size int64) {
+				if actual == claim {
+					return
+				}
+				logger.Warn("wallet: body hash mismatch, the server will reject the signature of the request. "+
+					"The body was altered after signing, for instance by a middleware.",
+					slog.String("path", req.URL.Path),
+					slog.String("signedBodyHash", claim),
+					slog.String("actualBodyHash", actual),
+					slog.Int64("size", size),
+				)
+			}}
+			if req.Body == nil || req.Body == http.NoBody {
+				body.ReadCloser = http.NoBody
+				body.finish()
+				return next.RoundTrip(req)
+			}
+			body.ReadCloser = req.Body
+			req.Body = body
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// hashingBody is a request body hashing the bytes read, calling report with the hex-encoded hash and the
+// size of the body once it is read entirely.
+type hashingBody struct {
+	io.ReadCloser
+	hash     hash.Hash
+	size     int64
+	report   func(actual string, size int64)
+	reported bool
+}
+
+func (b *hashingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	b.size += int64(n)
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *hashingBody) finish() {
+	if !b.reported {
+		b.reported = true
+		b.report(fmt.Sprintf("%x", b.hash.Sum(nil)), b.size)
+	}
+}
+
+// bodyHashClaim returns the bodyHash claim of the token in authorization, a "Bearer <token>" header value.
+func bodyHashClaim(authorization string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(authorization, "Bearer "), ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed token")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	var payload tokenPayload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return "", err
+	}
+	return payload.BodyHash, nil
+}
+
+// roundTripperFunc is an adapter to use a function as an [http.RoundTripper].
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,92 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVerifyBodyHash(t *testing.T) {
+	var logs bytes.Buffer
+
+	mutate := false
+	mutateBody := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if mutate {
+				b, _ := io.ReadAll(req.Body)
+				b = append(b, '\n')
+				req.Body = io.NopCloser(bytes.NewReader(b))
+				req.ContentLength = int64(len(b))
+			}
+			return next.RoundTrip(req)
+		})
+	}
+	o := &Options{
+		VerifyBodyHash: true,
+		Logger:         slog.New(slog.NewTextHandler(&logs, nil)),
+		Middlewares:    []func(http.RoundTripper) http.RoundTripper{mutateBody},
+	}
+	c := newTestClient(t, o, func(req *http.Request) (*http.Response, error) {
+		if _, err := io.ReadAll(req.Body); err != nil {
+			t.Fatal(err)
+		}
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(logs.String(), "body hash mismatch") {
+		t.Errorf("got a warning for an unaltered body:\n%s", logs.String())
+	}
+
+	mutate = true
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), "body hash mismatch") {
+		t.Errorf("got no warning for an altered body:\n%s", logs.String())
+	}
+	// only the hashes and the size of the body are logged.
+	if strings.Contains(logs.String(), "list_banks") || !strings.Contains(logs.String(), "size=") {
+		t.Errorf("got the body logged:\n%s", logs.String())
+	}
+
+	// another client built from the same options verifies the hash once.
+	logs.Reset()
+	c = New(o)
+	c.SetCredentials(testKeyID, testECPrivateKeyPEM(t))
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(logs.String(), "body hash mismatch"); got != 1 {
+		t.Errorf("second client: got %d warnings, want 1:\n%s", got, logs.String())
+	}
+}
+
+func TestVerifyBodyHashLargeBody(t *testing.T) {
+	var logs bytes.Buffer
+	c := newTestClient(t, &Options{
+		VerifyBodyHash:            true,
+		Logger:                    slog.New(slog.NewTextHandler(&logs, nil)),
+		LargeCommandBodyThreshold: 1024,
+	}, func(req *http.Request) (*http.Response, error) {
+		if _, err := io.Copy(io.Discard, req.Body); err != nil {
+			t.Fatal(err)
+		}
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+	input := map[string]string{"reference": strings.Repeat("a", 4096)}
+	var output map[string]any
+	if err := c.command(context.Background(), "test", input, &output); err != nil {
+		t.Fatal(err)
+	}
+	// the streamed body is hashed as it is sent.
+	if logs.Len() != 0 {
+		t.Errorf("got warnings for an unaltered large body:\n%s", logs.String())
+	}
+}
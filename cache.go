@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// responseCache caches the response bodies of queries listed in [Options.CacheableOperations].
+type responseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	operations map[Operation]struct{}
+	entries    map[[sha256.Size]byte]cacheEntry
+	// version is the latest data version returned by the server, see dataVersionHeader.
+	version string
+}
+
+// dataVersionHeader is the header in which the server returns the version of its reference data.
+const dataVersionHeader string = "X-Data-Version"
+
+type cacheEntry struct {
+	body      []byte
+	expiresAt time.Time
+	// version is the data version of body.
+	version string
+}
+
+func newResponseCache(ttl time.Duration, operations []Operation) *responseCache {
+	c := &responseCache{
+		ttl:        ttl,
+		operations: make(map[Operation]struct{}, len(operations)),
+		entries:    map[[sha256.Size]byte]cacheEntry{},
+	}
+	for _, name := range operations {
+		c.operations[name] = struct{}{}
+	}
+	return c
+}
+
+// cacheable reports whether the responses of the operation are cached. A nil cache caches nothing.
+func (c *responseCache) cacheable(name Operation) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.operations[name]
+	return ok
+}
+
+// get returns the cached response body of the request body reqBody.
+func (c *responseCache) get(reqBody []byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := sha256.Sum256(reqBody)
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.body, true
+}
+
+func (c *responseCache) set(reqBody []byte, respBody []byte, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[sha256.Sum256(reqBody)] = cacheEntry{
+		body:      respBody,
+		expiresAt: time.Now().Add(c.ttl),
+		version:   version,
+	}
+}
+
+// observeVersion evicts the entries of a data version other than version, returned by the server
+// with any response, so reference data is refreshed as soon as it changes rather than after the TTL.
+// A nil cache or an empty version does nothing.
+func (c *responseCache) observeVersion(version string) {
+	if c == nil || version == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version == c.version {
+		return
+	}
+	c.version = version
+	for key, entry := range c.entries {
+		if entry.version != version {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *responseCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// InvalidateCache removes all the responses and funds cached by the client, see [Options.CacheTTL] and
+// [Options.FundCacheTTL].
+func (c *Client) InvalidateCache() {
+	c.cache.invalidate()
+	c.funds.invalidate()
+}
@@ -0,0 +1,183 @@
+package wallet
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newCountingClient returns a cached client and a pointer to the number of requests it sent.
+func newCountingClient(t *testing.T, ttl time.Duration) (*Client, *int) {
+	t.Helper()
+	sent := 0
+	c := newTestClient(t, &Options{
+		CacheTTL:            ttl,
+		CacheableOperations: []Operation{OperationListBanks},
+	}, func(req *http.Request) (*http.Response, error) {
+		sent++
+		return jsonResponse(http.StatusOK, map[string]any{
+			"banks": []map[string]any{{"name": "Maybank", "bic": "MBBEMYKL"}},
+		}), nil
+	})
+	return c, &sent
+}
+
+func TestCacheHit(t *testing.T) {
+	c, sent := newCountingClient(t, time.Minute)
+	for i := 0; i < 3; i++ {
+		output, err := c.ListBanks(context.Background(), &ListBanksInput{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(output.Banks) != 1 || output.Banks[0].Bic != "MBBEMYKL" {
+			t.Fatalf("unexpected output %+v", output)
+		}
+	}
+	if *sent != 1 {
+		t.Errorf("sent %d requests, want 1", *sent)
+	}
+
+	c.InvalidateCache()
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if *sent != 2 {
+		t.Errorf("sent %d requests after invalidation, want 2", *sent)
+	}
+}
+
+func TestCacheMiss(t *testing.T) {
+	c, sent := newCountingClient(t, time.Minute)
+	ctx := context.Background()
+
+	// a different input is a different cache entry.
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListBanks(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if *sent != 2 {
+		t.Errorf("sent %d requests, want 2", *sent)
+	}
+
+	// operations not in CacheableOperations are never cached.
+	var output map[string]any
+	for i := 0; i < 2; i++ {
+		if err := c.query(ctx, OperationListDisplayCurrencies, struct{}{}, &output); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *sent != 4 {
+		t.Errorf("sent %d requests, want 4", *sent)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c, sent := newCountingClient(t, 10*time.Millisecond)
+	ctx := context.Background()
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if *sent != 2 {
+		t.Errorf("sent %d requests, want 2", *sent)
+	}
+}
+
+func TestCacheReferenceData(t *testing.T) {
+	var names []string
+	c := newTestClient(t, &Options{CacheTTL: time.Minute}, func(req *http.Request) (*http.Response, error) {
+		r := decodeTestRequest(t, req)
+		names = append(names, r.Name)
+		switch r.Name {
+		case "list_banks":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"banks": []map[string]any{
+					{"name": "Maybank", "bic": "MBBEMYKL", "features": []string{"duitnow", "fpx"}},
+					{"name": "CIMB", "bic": "CIBBMYKL", "features": []string{"fpx"}},
+				},
+			}), nil
+		case "list_display_currencies":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"displayCurrency": "MYR",
+				"currencies":      []map[string]any{{"id": "MYR", "label": "Malaysian Ringgit"}, {"id": "USD", "label": "US Dollar"}},
+			}), nil
+		}
+		t.Fatalf("unexpected request %q", r.Name)
+		return nil, nil
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		banks, err := c.ListBanks(ctx, &ListBanksInput{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(banks.Banks) != 2 || banks.Banks[0].Bic != "MBBEMYKL" || len(banks.Banks[0].Features) != 2 {
+			t.Errorf("got banks %+v", banks.Banks)
+		}
+		currencies, err := c.ListDisplayCurrencies(ctx, &ListDisplayCurrenciesInput{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if currencies.DisplayCurrency != "MYR" || len(currencies.Currencies) != 2 || currencies.Currencies[1].Label != "US Dollar" {
+			t.Errorf("got currencies %+v", currencies)
+		}
+	}
+	// the second calls within the TTL are served from the cache.
+	if len(names) != 2 {
+		t.Errorf("got requests %v, want one per operation", names)
+	}
+}
+
+func TestCacheDataVersion(t *testing.T) {
+	version, bic := "v1", "MBBEMYKL"
+	sent := 0
+	c := newTestClient(t, &Options{CacheTTL: time.Hour}, func(req *http.Request) (*http.Response, error) {
+		sent++
+		var resp *http.Response
+		switch decodeTestRequest(t, req).Name {
+		case "list_banks":
+			resp = jsonResponse(http.StatusOK, map[string]any{"banks": []map[string]any{{"name": "Maybank", "bic": bic}}})
+		default:
+			resp = jsonResponse(http.StatusOK, map[string]any{})
+		}
+		resp.Header.Set("X-Data-Version", version)
+		return resp, nil
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListBanks(ctx, &ListBanksInput{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sent != 1 {
+		t.Fatalf("sent %d requests, want 1", sent)
+	}
+
+	// a response with a new version evicts the cached banks.
+	version, bic = "v2", "MBBEMYKLXXX"
+	if _, err := c.ListClientAccounts(ctx, &ListClientAccountsInput{}); err != nil {
+		t.Fatal(err)
+	}
+	output, err := c.ListBanks(ctx, &ListBanksInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 3 || output.Banks[0].Bic != "MBBEMYKLXXX" {
+		t.Errorf("sent %d requests and got banks %+v, want the banks refreshed", sent, output.Banks)
+	}
+	// the refreshed banks are cached again.
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if sent != 3 {
+		t.Errorf("sent %d requests, want 3", sent)
+	}
+}
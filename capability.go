@@ -0,0 +1,87 @@
+package wallet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// accountCapabilitiesTTL specifies how long the accounts fetched for the capability checks are kept.
+const accountCapabilitiesTTL = time.Minute
+
+// accountCapabilities holds the accounts fetched to check the capability flags of the requester locally
+// when [Options.PreflightCapabilityChecks] is set.
+type accountCapabilities struct {
+	mu       sync.Mutex
+	accounts map[string]accountCapabilitiesEntry
+}
+
+type accountCapabilitiesEntry struct {
+	account ClientAccount
+	fetched time.Time
+}
+
+// capabilities specifies the capability flag of ClientAccount checked per command, along with the
+// experience the flag is available for, empty when available for all, and the action it allows.
+// CanDeposit and CanWithdraw are not checked as the SDK has no deposit or withdrawal command.
+var capabilities = map[Operation]struct {
+	experience AccountExperience
+	allowed    func(account ClientAccount) bool
+	action     string
+}{
+	OperationCreateInvestmentRequest: {AccountExperienceFundManagement, func(a ClientAccount) bool { return a.CanInvest }, "investment"},
+	OperationCreateRedemptionRequest: {AccountExperienceFundManagement, func(a ClientAccount) bool { return a.CanRedeem }, "redemption"},
+	OperationCreateSwitchRequest:     {AccountExperienceFundManagement, func(a ClientAccount) bool { return a.CanSwitch }, "switch"},
+	OperationUpdateAccountName:       {"", func(a ClientAccount) bool { return a.CanUpdateAccountName }, "account name update"},
+}
+
+// accountInput is implemented by the command inputs checked against the capability flags of their account,
+// once validated.
+type accountInput interface {
+	accountID() string
+}
+
+func (input *CreateInvestmentRequestInput) accountID() string { return input.AccountID }
+func (input *CreateRedemptionRequestInput) accountID() string { return input.AccountID }
+func (input *CreateSwitchRequestInput) accountID() string     { return input.AccountID }
+func (input *UpdateAccountNameInput) accountID() string       { return input.AccountID }
+
+// checkCapability checks the account accountID, fetched within accountCapabilitiesTTL, allows the requester to send
+// the command name. Accounts not returned by the server, and accounts of an experience the capability flag is not
+// available for, are left to the server to check.
+func (c *Client) checkCapability(ctx context.Context, accountID string, name Operation) error {
+	capability, ok := capabilities[name]
+	if !ok {
+		return nil
+	}
+	r := &c.accountCapabilities
+	r.mu.Lock()
+	entry, ok := r.accounts[accountID]
+	r.mu.Unlock()
+	if !ok || time.Since(entry.fetched) > accountCapabilitiesTTL {
+		// the account is fetched without holding the lock, so commands are not serialized behind a slow fetch.
+		output, err := c.ListClientAccounts(ctx, &ListClientAccountsInput{AccountIDs: []string{accountID}})
+		if err != nil {
+			return err
+		}
+		r.mu.Lock()
+		if r.accounts == nil {
+			r.accounts = map[string]accountCapabilitiesEntry{}
+		}
+		delete(r.accounts, accountID)
+		if output != nil {
+			for _, account := range output.Accounts {
+				r.accounts[account.ID] = accountCapabilitiesEntry{account: account, fetched: time.Now()}
+			}
+		}
+		entry, ok = r.accounts[accountID]
+		r.mu.Unlock()
+		if !ok {
+			return nil
+		}
+	}
+	if capability.experience != "" && entry.account.Experience != capability.experience || capability.allowed(entry.account) {
+		return nil
+	}
+	return Error{Code: ErrInsufficientAccess, Message: "wallet: account " + accountID + " does not allow " + capability.action + " requests."}
+}
@@ -0,0 +1,128 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPreflightCapabilityChecks(t *testing.T) {
+	var names []string
+	c := newTestClient(t, &Options{PreflightCapabilityChecks: true}, func(req *http.Request) (*http.Response, error) {
+		name := decodeTestRequest(t, req).Name
+		names = append(names, name)
+		if name == "list_client_accounts" {
+			return jsonResponse(http.StatusOK, map[string]any{"accounts": []map[string]any{
+				{"id": "acc_1", "experience": "fundmanagement", "canInvest": true, "canRedeem": false, "canSwitch": true},
+			}}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"requestId": "req_1"}), nil
+	})
+	ctx := context.Background()
+
+	if _, err := c.CreateInvestmentRequest(ctx, &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1", FundClassSequence: 1, Amount: NewAmount(1000, "MYR")}); err != nil {
+		t.Fatal(err)
+	}
+	var werr Error
+	_, err := c.CreateRedemptionRequest(ctx, &CreateRedemptionRequestInput{AccountID: "acc_1", FundID: "fund_1", RequestedAmount: NewAmount(1000, "MYR")})
+	if !errors.As(err, &werr) || werr.Code != ErrInsufficientAccess {
+		t.Errorf("got error %v, want %s", err, ErrInsufficientAccess)
+	}
+	// the account is fetched once, and the disallowed redemption is not sent.
+	if len(names) != 2 || names[0] != "list_client_accounts" || names[1] != "create_investment_request" {
+		t.Errorf("got requests %v, want list_client_accounts and create_investment_request", names)
+	}
+
+	// accounts not returned by the server are left to the server to check.
+	names = nil
+	if _, err := c.CreateSwitchRequest(ctx, &CreateSwitchRequestInput{AccountID: "acc_unknown", SwitchFromFundID: "fund_1", SwitchToFundID: "fund_2", RequestedAmount: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[1] != "create_switch_request" {
+		t.Errorf("got requests %v, want the switch request sent", names)
+	}
+
+	// the account name update is checked for every experience.
+	names = nil
+	_, err = c.UpdateAccountName(ctx, &UpdateAccountNameInput{AccountID: "acc_1", AccountName: "Savings"})
+	if !errors.As(err, &werr) || werr.Code != ErrInsufficientAccess {
+		t.Errorf("got error %v, want %s", err, ErrInsufficientAccess)
+	}
+	if len(names) != 0 {
+		t.Errorf("got requests %v, want none", names)
+	}
+
+	// invalid inputs fail before the account is fetched.
+	c.accountCapabilities.accounts = nil
+	_, err = c.CreateInvestmentRequest(ctx, &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1"})
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got error %v, want %s", err, ErrInvalidParameter)
+	}
+	if len(names) != 0 {
+		t.Errorf("got requests %v, want none", names)
+	}
+}
+
+func TestPreflightCapabilityChecksNullAccounts(t *testing.T) {
+	var names []string
+	c := newTestClient(t, &Options{PreflightCapabilityChecks: true}, func(req *http.Request) (*http.Response, error) {
+		name := decodeTestRequest(t, req).Name
+		names = append(names, name)
+		if name == "list_client_accounts" {
+			return jsonResponse(http.StatusOK, nil), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"requestId": "req_1"}), nil
+	})
+
+	// a null body returns no account, the command is left to the server to check.
+	if _, err := c.CreateInvestmentRequest(context.Background(), &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1", FundClassSequence: 1, Amount: NewAmount(1000, "MYR")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[1] != "create_investment_request" {
+		t.Errorf("got requests %v, want the investment request sent", names)
+	}
+}
+
+func TestPreflightCapabilityChecksFetchOutsideLock(t *testing.T) {
+	release := make(chan struct{})
+	fetching := make(chan struct{}, 1)
+	c := newTestClient(t, &Options{PreflightCapabilityChecks: true}, func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		fetching <- struct{}{}
+		<-release
+		return jsonResponse(http.StatusOK, map[string]any{"accounts": []map[string]any{
+			{"id": "acc_1", "experience": "fundmanagement", "canInvest": true},
+		}}), nil
+	})
+
+	slow := make(chan error, 1)
+	go func() {
+		slow <- c.checkCapability(context.Background(), "acc_1", OperationCreateInvestmentRequest)
+	}()
+	<-fetching
+
+	// a command whose context is done is not held behind the slow fetch.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.checkCapability(ctx, "acc_2", OperationCreateInvestmentRequest)
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("capability check blocked behind the slow fetch")
+	}
+
+	close(release)
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+}
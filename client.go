@@ -2,215 +2,829 @@ package wallet
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
-	"encoding/json"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"mime"
 	"net/http"
+	"net/http/httptrace"
 	"net/http/httputil"
+	"runtime/debug"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Version is the version of the SDK, sent in the default User-Agent header.
+const Version string = "0.0.8"
+
 const (
 	endpoint  string = "https://external-api.wallet.halogen.my"
-	version   string = "0.0.8"
-	userAgent string = "wallet/" + version + " lang/go"
+	userAgent string = "halogen-go-sdk/" + Version
 )
 
 type queryInput struct {
-	Name    string      `json:"name"`
+	Name    Operation   `json:"name"`
 	Payload interface{} `json:"payload"`
 }
 
-func (c *Client) query(ctx context.Context, name string, input interface{}, output interface{}) error {
-	// retriedCount increments on >= 500 errors
-	retriedCount := 0
-retry:
-	body := queryInput{
-		Name:    name,
-		Payload: input,
+func (c *Client) query(ctx context.Context, name Operation, input interface{}, output interface{}, opts ...CallOption) (err error) {
+	defer c.recoverPanic(&err)
+	call := newCallOptions(opts)
+	ctx, cancel := c.withTimeout(ctx, name, call)
+	defer cancel()
+	body, err := c.encodeBody(queryInput{Name: name, Payload: input})
+	if err != nil {
+		return err
+	}
+	// calls with custom headers may get a different response, they are not cached.
+	cacheable := c.cache.cacheable(name) && len(call.header) == 0
+	if cacheable {
+		if respBody, ok := c.cache.get(body); ok {
+			return c.decodeBytes(respBody, output)
+		}
 	}
-	var jsonBuffer bytes.Buffer
-	if err := json.NewEncoder(&jsonBuffer).Encode(body); err != nil {
+	resp, err := c.send(ctx, &request{
+		name:              name,
+		uri:               "/query",
+		body:              body,
+		call:              call,
+		retryServerErrors: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if !cacheable {
+		return c.decode(resp, output)
+	}
+	if err := checkContentType(resp); err != nil {
 		return err
 	}
-	reqBody := bytes.TrimRight(jsonBuffer.Bytes(), "\n")
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/query", bytes.NewReader(reqBody))
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
-	jsonBuffer.Reset()
-	req.Header.Set("User-Agent", userAgent)
+	if err := c.decodeBytes(respBody, output); err != nil {
+		return err
+	}
+	c.cache.set(body, respBody, resp.Header.Get(dataVersionHeader))
+	return nil
+}
 
-	o := c.options
-	keyID := ""
-	privateKeyPEM := []byte{}
-	if o.CredentialsLoaderFunc == nil {
-		keyID, privateKeyPEM, err = c.defaultCredentialsLoaderFunc()
-		if err != nil {
+// queryRaw sends a query and returns the successful response without decoding its body,
+// allowing the caller to stream it.
+//
+// The caller must close the body of the returned response.
+func (c *Client) queryRaw(ctx context.Context, name Operation, input interface{}, opts ...CallOption) (resp *http.Response, err error) {
+	defer c.recoverPanic(&err)
+	body, err := c.encodeBody(queryInput{Name: name, Payload: input})
+	if err != nil {
+		return nil, err
+	}
+	call := newCallOptions(opts)
+	ctx, cancel := c.withTimeout(ctx, name, call)
+	resp, err = c.send(ctx, &request{
+		name:              name,
+		uri:               "/query",
+		body:              body,
+		call:              call,
+		retryServerErrors: true,
+		streamed:          true,
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the timeout applies until the body is read.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose is a response body cancelling the context of its request when closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// defaultMaxResponseBytes is the default of Options.MaxResponseBytes.
+const defaultMaxResponseBytes = 10 << 20
+
+// maxResponseBytes returns the maximum size of the response body of r, not positive when unlimited.
+func (c *Client) maxResponseBytes(r *request) int64 {
+	if r.call.maxResponseBytes != 0 {
+		return r.call.maxResponseBytes
+	}
+	if r.streamed {
+		return 0
+	}
+	return c.options.MaxResponseBytes
+}
+
+// maxBytesBody is a response body failing with ErrResponseTooLarge once more than limit bytes are read.
+type maxBytesBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func (b *maxBytesBody) Read(p []byte) (int, error) {
+	tooLarge := Error{Code: ErrResponseTooLarge, Message: fmt.Sprintf("wallet: response body exceeds %d bytes. Use WithMaxResponseBytes to raise the limit of the call.", b.limit)}
+	if b.remaining < 0 {
+		return 0, tooLarge
+	}
+	// read one more byte than remaining to tell a body of exactly limit bytes from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+	n = int(b.remaining)
+	b.remaining = -1
+	return n, tooLarge
+}
+
+// withTimeout returns ctx bound by the timeout of the call set with WithTimeout, or of its operation
+// in OperationTimeouts, if any.
+func (c *Client) withTimeout(ctx context.Context, name Operation, call *callOptions) (context.Context, context.CancelFunc) {
+	timeout := call.timeout
+	if timeout <= 0 {
+		timeout = c.options.OperationTimeouts[name]
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+type commandInput struct {
+	Name    Operation   `json:"name"`
+	Payload interface{} `json:"payload"`
+}
+
+// Validator is implemented by the command inputs to validate them locally, so invalid commands
+// are rejected before being signed and sent.
+type Validator interface {
+	// Validate returns an [Error] describing the first invalid parameter, if any.
+	Validate() error
+}
+
+// errCredentialsNotSet is returned when no credentials are set with SetCredentials.
+var errCredentialsNotSet = Error{Code: ErrCredentialsNotSet, Message: "credentials are not set. You may either use SetCredentials or provide CredentialsLoaderFunc upon client initialization."}
+
+// errMissingInput is returned when the input of a command is nil.
+var errMissingInput = Error{Code: ErrMissingParameter, Message: "wallet: input is required."}
+
+func (c *Client) command(ctx context.Context, name Operation, input interface{}, output interface{}, opts ...CallOption) (err error) {
+	defer c.recoverPanic(&err)
+	if v, ok := input.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	if input, ok := input.(accountInput); ok && c.options.PreflightCapabilityChecks {
+		if err := c.checkCapability(ctx, input.accountID(), name); err != nil {
 			return err
 		}
+	}
+	large, err := c.encodeLargeBody(commandInput{Name: name, Payload: input})
+	if err != nil {
+		return err
+	}
+	var body []byte
+	var fingerprint string
+	if large != nil {
+		defer large.close()
+		fingerprint = fmt.Sprintf("%x", large.hash)
 	} else {
-		keyID, privateKeyPEM, err = o.CredentialsLoaderFunc()
-		if err != nil {
+		if body, err = c.encodeBody(commandInput{Name: name, Payload: input}); err != nil {
 			return err
 		}
+		fingerprint = fmt.Sprintf("%x", sha256.Sum256(body))
 	}
-	// clean up the memory when CredentialsLoaderFunc is set.
-	shouldCleanMemory := o.CredentialsLoaderFunc != nil
-	token, err := newToken(keyID, "/query", reqBody, 10*time.Second, shouldCleanMemory)
+	// the same command is sent with the same idempotency key until the server responds definitively.
+	store := c.options.IdempotencyStore
+	idempotencyKey, ok, err := store.Load(ctx, fingerprint)
 	if err != nil {
 		return err
 	}
-	signature, err := token.signAndFormat(privateKeyPEM)
+	if !ok {
+		keyBuffer := make([]byte, 16)
+		if _, err := rand.Read(keyBuffer); err != nil {
+			return fmt.Errorf("wallet: command: failed to read random bytes. err=%v", err)
+		}
+		idempotencyKey = fmt.Sprintf("%x", keyBuffer)
+		if err := store.Store(ctx, fingerprint, idempotencyKey, c.options.IdempotencyKeyTTL); err != nil {
+			return err
+		}
+	}
+	// only retry rate limited errors, and once when no response is received as the idempotency key
+	// prevents the command from being processed twice.
+	opts = append(opts, withHeader(http.Header{"Idempotency-Key": []string{idempotencyKey}}))
+	r := &request{
+		name:                name,
+		uri:                 "/command",
+		body:                body,
+		large:               large,
+		call:                newCallOptions(opts),
+		retryTransportError: true,
+	}
+	ctx, cancel := c.withTimeout(ctx, name, r.call)
+	defer cancel()
+	if c.options.CompressRequests && large == nil && len(body) > compressionThreshold {
+		if r.body, err = gzipBody(body); err != nil {
+			return err
+		}
+		r.contentEncoding = "gzip"
+	}
+	resp, err := c.send(ctx, r)
+	var sdkErr Error
+	if err == nil || errors.As(err, &sdkErr) && sdkErr.StatusCode < http.StatusInternalServerError {
+		if err := store.Delete(ctx, fingerprint); err != nil && c.options.Debug {
+			log.Printf("WARN: failed to delete idempotency key of %s. err=%v\n", name, err)
+		}
+	}
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+signature)
+	defer resp.Body.Close()
+	return c.decode(resp, output)
+}
+
+// encodeBody encodes the body of a request with the codec of the client. The bodyHash claim of the
+// request is computed over these exact bytes.
+func (c *Client) encodeBody(body interface{}) ([]byte, error) {
+	return c.options.Codec.Marshal(body)
+}
+
+// compressionThreshold is the size in bytes above which command bodies are compressed, see [Options.CompressRequests].
+const compressionThreshold = 1024
+
+// gzipBody returns body compressed with gzip.
+func gzipBody(body []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	w := gzip.NewWriter(&buffer)
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// request describes a request sent by [Client.send].
+type request struct {
+	// name is the operation of the request.
+	name Operation
+	// uri is the path of the API, either "/query" or "/command".
+	uri string
+	// body is the JSON encoded request body, encoded with contentEncoding if set.
+	body []byte
+	// large replaces body when the body is too large to be held in memory, see Options.LargeCommandBodyThreshold.
+	large *largeBody
+	// contentEncoding specifies the Content-Encoding header of body, if any.
+	contentEncoding string
+	// call specifies the options of the call.
+	call *callOptions
+	// retryServerErrors reports whether to retry >= 500 errors.
+	retryServerErrors bool
+	// retryTransportError reports whether to retry once when no response is received, for instance,
+	// when the connection is reset, the server having then not processed the request.
+	retryTransportError bool
+	// streamed reports whether the successful response body is streamed to the caller rather than
+	// buffered, Options.MaxResponseBytes then does not apply.
+	streamed bool
+}
+
+// send signs and sends r, retrying rate-limited requests and, when r.retryServerErrors is set, server errors.
+// Responses with status code >= 400 are returned as [Error].
+//
+// The caller must close the body of the returned response.
+func (c *Client) send(ctx context.Context, r *request) (resp *http.Response, err error) {
+	if c.invalidOptionErr != nil {
+		return nil, c.invalidOptionErr
+	}
+	if r.call.withoutAuth && !slices.Contains(unauthenticatedOperations, r.name) {
+		return nil, Error{Code: ErrInvalidParameter, Message: "wallet: " + string(r.name) + " cannot be called WithoutAuth."}
+	}
+	defer c.reportSlowRequest(r, time.Now())
+	// retriedCount increments on >= 500 errors
+	retriedCount := 0
+	// candidate increments when the signature is rejected, see AddCredentials
+	candidate := 0
+	// attempt is the number of the attempt being sent, from 1
+	attempt := 0
+	// errors tell the operation and the attempt producing them, see Error
+	defer func() {
+		if err == nil || attempt == 0 {
+			return
+		}
+		if sdkErr, ok := err.(Error); ok {
+			sdkErr.Operation, sdkErr.Attempt = r.name, attempt
+			err = sdkErr
+			return
+		}
+		err = fmt.Errorf("wallet: operation=%s attempt=%d: %w", r.name, attempt, err)
+	}()
+	// transportRetried is set once retried as no response was received
+	transportRetried := false
+	// signed is the token of the previous attempt
+	var signed *signedToken
+retry:
+	attempt++
+	reqBody := r.body
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+r.uri, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.options.UserAgent)
+	if r.contentEncoding != "" {
+		req.Header.Set("Content-Encoding", r.contentEncoding)
+	}
+	if c.options.Language != "" {
+		req.Header.Set("Accept-Language", c.options.Language)
+	}
+	for key, values := range r.call.header {
+		req.Header[key] = values
+	}
+
+	o := c.options
+	// public reference data is sent unsigned, see WithoutAuth
+	if !r.call.withoutAuth {
+		// the token is reused by the next attempts while valid so they are sent byte-for-byte identical,
+		// unless other credentials are tried
+		if signed == nil || signed.candidate != candidate || time.Until(signed.expiresAt) < tokenTTL/2 {
+			if signed, err = c.signRequest(ctx, r, candidate); err != nil {
+				return nil, err
+			}
+		}
+		req.Header.Set("Authorization", "Bearer "+signed.token)
+	}
 	if o.Debug {
-		reqB, err := httputil.DumpRequestOut(req, true)
+		dump := req.Clone(req.Context())
+		dump.Header = c.redactHeader(req.Header)
+		dump.Body = io.NopCloser(bytes.NewReader(reqBody))
+		// large bodies are not logged
+		reqB, err := httputil.DumpRequestOut(dump, r.large == nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		log.Printf("INFO: sending request\n%s\n", string(reqB))
 	}
-	resp, err := o.HTTPClient.Do(req)
+	var recorder *traceRecorder
+	if r.call.trace != nil {
+		recorder = newTraceRecorder()
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), recorder.clientTrace()))
+	}
+	if err := c.inflight.acquire(ctx); err != nil {
+		return nil, err
+	}
+	if r.large != nil {
+		if err := r.large.attach(req); err != nil {
+			c.inflight.release()
+			return nil, err
+		}
+	}
+	resp, err = c.httpClient.Do(req)
+	if recorder != nil {
+		r.call.trace(recorder.done())
+	}
 	if err != nil {
-		return err
+		c.inflight.release()
+		if delay, ok := c.shouldRetry(ctx, r, attempt, nil, err); ok && c.retryBudget.take() {
+			c.logRetry(r, attempt+1, Error{}, "should_retry", delay)
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			goto retry
+		}
+		if r.retryTransportError && !transportRetried && !r.call.disableRetry && ctx.Err() == nil && c.retryBudget.take() {
+			transportRetried = true
+			c.logRetry(r, attempt+1, Error{}, "transport_error", 0)
+			goto retry
+		}
+		return nil, err
+	}
+	if limit := c.maxResponseBytes(r); limit > 0 {
+		resp.Body = &maxBytesBody{ReadCloser: resp.Body, limit: limit, remaining: limit}
+	}
+	if r.call.responseMeta != nil {
+		setResponseMeta(r.call.responseMeta, resp, c.redactHeader(resp.Header))
 	}
+	c.cache.observeVersion(resp.Header.Get(dataVersionHeader))
 	if o.Debug {
-		r, err := httputil.DumpResponse(resp, true)
+		dump := *resp
+		dump.Header = c.redactHeader(resp.Header)
+		respB, err := httputil.DumpResponse(&dump, true)
+		// the body is read by the dump and replaced with a copy.
+		resp.Body = dump.Body
 		if err != nil {
-			return err
+			resp.Body.Close()
+			c.inflight.release()
+			return nil, err
 		}
-		log.Printf("INFO: received response\n%s\n", r)
+		log.Printf("INFO: received response\n%s\n", respB)
+	}
+	// the slot is released once the caller closes the body, which the debug dump above replaces.
+	if c.inflight != nil {
+		resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: c.inflight.release}
 	}
-	keyID = ""
 	req = nil
 	if resp.StatusCode >= 400 {
+		if err := checkContentType(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
 		sdkErr := Error{
 			StatusCode: resp.StatusCode,
 		}
-		if err := json.NewDecoder(resp.Body).Decode(&sdkErr); err != nil {
-			return sdkErr
+		err := c.decodeBody(resp, &sdkErr)
+		resp.Body.Close()
+		if err != nil {
+			return nil, sdkErr
+		}
+		if sdkErr.Code == serverCodeBodyHashMismatch {
+			sdkErr.Code = ErrBodyHashMismatch
+		}
+		// signature rejected, try the next candidate credentials
+		if resp.StatusCode == http.StatusUnauthorized && (sdkErr.Code == ErrInvalidAuthSignature || sdkErr.Code == ErrInvalidPublicKey) &&
+			candidate+1 < c.credentialsCount() {
+			candidate++
+			c.logRetry(r, attempt+1, sdkErr, "signature_rejected", 0)
+			goto retry
+		}
+		// the caller's decision replaces the built-in one below
+		if r.retryServerErrors && o.ShouldRetry != nil {
+			delay, ok := c.shouldRetry(ctx, r, attempt, resp, sdkErr)
+			if !ok {
+				return nil, sdkErr
+			}
+			if !c.retryBudget.take() {
+				return nil, retryBudgetExhausted(sdkErr)
+			}
+			c.logRetry(r, attempt+1, sdkErr, "should_retry", delay)
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			goto retry
+		}
+		// under maintenance, retrying before it ends would only burn the retry budget, and errors
+		// known to be deterministic fail the same way when retried
+		if sdkErr.Code == ErrMaintenance || slices.Contains(c.options.NonRetryableCodes, sdkErr.Code) {
+			return nil, sdkErr
 		}
 		// rate-limited
-		if resp.StatusCode == http.StatusTooManyRequests {
+		if resp.StatusCode == http.StatusTooManyRequests && !r.call.disableRetry {
 			i, err := strconv.ParseInt(resp.Header.Get("Retry-After"), 10, 64)
 			if err != nil {
-				return sdkErr
+				return nil, sdkErr
+			}
+			if !c.retryBudget.take() {
+				return nil, retryBudgetExhausted(sdkErr)
+			}
+			retryAfter := jitterRetryAfter(time.Duration(i)*time.Second, c.options.MaxRetryAfter, mathrand.Int64N)
+			c.logRetry(r, attempt+1, sdkErr, "rate_limited", retryAfter)
+			if err := sleep(ctx, retryAfter); err != nil {
+				return nil, err
 			}
-			time.Sleep(time.Duration(i) * time.Second)
 			goto retry
 		}
 		// retry server error
-		if resp.StatusCode >= http.StatusInternalServerError {
+		if r.retryServerErrors && !r.call.disableRetry && resp.StatusCode >= http.StatusInternalServerError {
 			if retriedCount >= c.options.MaxReadRetry-1 {
-				return sdkErr
+				return nil, sdkErr
+			}
+			if !c.retryBudget.take() {
+				return nil, retryBudgetExhausted(sdkErr)
 			}
 			retriedCount++
-			time.Sleep(c.options.RetryInterval)
+			c.logRetry(r, attempt+1, sdkErr, "server_error", c.options.RetryInterval)
+			if err := sleep(ctx, c.options.RetryInterval); err != nil {
+				return nil, err
+			}
 			goto retry
 		}
-		return sdkErr
+		return nil, sdkErr
+	}
+	if err := c.responseVerifier.verify(ctx, resp); err != nil {
+		resp.Body.Close()
+		return nil, err
 	}
-	return json.NewDecoder(resp.Body).Decode(&output)
+	if candidate > 0 {
+		c.preferCredentials(candidate)
+	}
+	return resp, nil
 }
 
-type commandInput struct {
-	Name    string      `json:"name"`
-	Payload interface{} `json:"payload"`
+// tokenTTL specifies how long the token signing a request is valid.
+const tokenTTL = 10 * time.Second
+
+// signedToken is a token signing a request, see [Client.signRequest].
+type signedToken struct {
+	// token is the signed JWT.
+	token string
+	// candidate is the candidate credentials signing token, see loadCredentials.
+	candidate int
+	// expiresAt specifies when token expires.
+	expiresAt time.Time
 }
 
-func (c *Client) command(ctx context.Context, name string, input interface{}, output interface{}) error {
-	// only retry rate limited errors.
-retry:
-	body := commandInput{
-		Name:    name,
-		Payload: input,
+// signRequest returns a token signing r with the candidate-th credentials, or the signer of SignerFunc.
+func (c *Client) signRequest(ctx context.Context, r *request, candidate int) (*signedToken, error) {
+	o := c.options
+	var (
+		keyID             string
+		privateKeyPEM     []byte
+		shouldCleanMemory bool
+		signer            crypto.Signer
+		alg               string
+		err               error
+	)
+	if o.SignerFunc != nil {
+		keyID, signer, alg, err = c.loadSigner(ctx)
+	} else if o.CacheParsedKeys && o.CredentialsLoaderFunc == nil {
+		keyID, signer, alg, err = c.loadCachedSigner(candidate)
+	} else {
+		keyID, privateKeyPEM, shouldCleanMemory, err = c.loadCredentials(candidate)
 	}
-	var jsonBuffer bytes.Buffer
-	if err := json.NewEncoder(&jsonBuffer).Encode(body); err != nil {
-		return err
+	if err != nil {
+		return nil, err
 	}
-	reqBody := bytes.TrimRight(jsonBuffer.Bytes(), "\n")
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/command", bytes.NewReader(reqBody))
+	random := c.randReader
+	if random == nil {
+		random = rand.Reader
+	}
+	token, err := newTokenFromReader(random, o.NonceBytes, keyID, r.uri, r.body, tokenTTL, shouldCleanMemory)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	jsonBuffer.Reset()
-	req.Header.Set("User-Agent", userAgent)
-
-	o := c.options
-	keyID := ""
-	privateKeyPEM := []byte{}
-	if o.CredentialsLoaderFunc == nil {
-		keyID, privateKeyPEM, err = c.defaultCredentialsLoaderFunc()
-		if err != nil {
-			return err
-		}
+	if r.large != nil {
+		token.Payload.BodyHash = fmt.Sprintf("%x", r.large.hash)
+	}
+	if o.Subject != "" {
+		token.Payload.Sub = o.Subject
+	}
+	token.Payload.Aud = o.Audience
+	token.Header.Typ = o.TokenType
+	token.Payload.Extra = o.ExtraClaims
+	if o.IncludeKidInHeader {
+		token.Header.Kid = keyID
+	}
+	var signature string
+	if signer != nil {
+		signature, err = token.signWith(signer, alg)
 	} else {
-		keyID, privateKeyPEM, err = o.CredentialsLoaderFunc()
-		if err != nil {
-			return err
-		}
+		signature, err = token.signAndFormat(privateKeyPEM)
 	}
-	// clean up the memory when CredentialsLoaderFunc is set.
-	shouldCleanMemory := o.CredentialsLoaderFunc != nil
-	token, err := newToken(keyID, "/command", reqBody, 10*time.Second, shouldCleanMemory)
 	if err != nil {
+		return nil, err
+	}
+	return &signedToken{
+		token:     signature,
+		candidate: candidate,
+		expiresAt: time.Unix(token.Payload.Exp, 0),
+	}, nil
+}
+
+// reportSlowRequest reports r, sent from start, when it took longer than SlowRequestThreshold.
+func (c *Client) reportSlowRequest(r *request, start time.Time) {
+	threshold := c.options.SlowRequestThreshold
+	if threshold <= 0 {
+		return
+	}
+	duration := time.Since(start)
+	if duration <= threshold {
+		return
+	}
+	c.options.Logger.Warn("wallet: slow request",
+		slog.String("operation", string(r.name)),
+		slog.Duration("duration", duration),
+		slog.Duration("threshold", threshold),
+	)
+	if c.options.OnSlowRequest != nil {
+		c.options.OnSlowRequest(r.name, duration)
+	}
+}
+
+// shouldRetry returns the delay before retrying the query r, which failed with resp or err, when
+// [Options.ShouldRetry] decides to retry it.
+func (c *Client) shouldRetry(ctx context.Context, r *request, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if !r.retryServerErrors || c.options.ShouldRetry == nil || r.call.disableRetry || ctx.Err() != nil {
+		return 0, false
+	}
+	retry, delay := c.options.ShouldRetry(attempt, resp, err)
+	return delay, retry
+}
+
+// logRetry logs in debug mode that r is retried after the error sdkErr, waiting for delay before the attempt-th attempt.
+func (c *Client) logRetry(r *request, attempt int, sdkErr Error, reason string, delay time.Duration) {
+	if !c.options.Debug {
+		return
+	}
+	c.options.Logger.Info("wallet: retrying request",
+		slog.String("operation", string(r.name)),
+		slog.Int("attempt", attempt),
+		slog.Int("status", sdkErr.StatusCode),
+		slog.String("code", sdkErr.Code),
+		slog.String("reason", reason),
+		slog.Duration("delay", delay),
+	)
+}
+
+// recoverPanic converts a panic, for instance raised while reading a malformed response body,
+// into an [ErrInternal] error assigned to *err so it does not crash the caller. The stack is
+// included in the error message in debug mode.
+//
+// It must be deferred.
+func (c *Client) recoverPanic(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	message := fmt.Sprintf("wallet: recovered from panic: %v", r)
+	if c.options.Debug {
+		message += "\n" + string(debug.Stack())
+		log.Printf("WARN: %s\n", message)
+	}
+	*err = Error{Code: ErrInternal, Message: message}
+}
+
+// retryBudgetExhausted returns the error of a retryable request that was not retried
+// as the retry budget is depleted.
+func retryBudgetExhausted(sdkErr Error) Error {
+	return Error{
+		StatusCode: sdkErr.StatusCode,
+		Code:       ErrRetryBudgetExhausted,
+		Message:    fmt.Sprintf("wallet: retry budget exhausted, not retrying %s: %s", sdkErr.Code, sdkErr.Message),
+	}
+}
+
+// contentTypeSnippetSize specifies how many bytes of an unexpected body are included in [ErrUnexpectedContentType].
+const contentTypeSnippetSize = 256
+
+// checkContentType returns [ErrUnexpectedContentType] when the body of resp is not JSON. A missing
+// Content-Type is assumed to be JSON.
+func checkContentType(resp *http.Response) error {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, contentTypeSnippetSize+1))
+	truncated := ""
+	if len(snippet) > contentTypeSnippetSize {
+		snippet, truncated = snippet[:contentTypeSnippetSize], "..."
+	}
+	return Error{
+		StatusCode: resp.StatusCode,
+		Code:       ErrUnexpectedContentType,
+		Message:    fmt.Sprintf("wallet: unexpected content type %q, body: %q%s", contentType, snippet, truncated),
+	}
+}
+
+// decode decodes the JSON body of resp into output.
+func (c *Client) decode(resp *http.Response, output interface{}) error {
+	if err := checkContentType(resp); err != nil {
 		return err
 	}
-	signature, err := token.signAndFormat(privateKeyPEM)
+	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+signature)
-	if o.Debug {
-		reqB, err := httputil.DumpRequestOut(req, true)
-		if err != nil {
-			return err
-		}
-		log.Printf("INFO: sending request\n%s\n", string(reqB))
-	}
-	resp, err := o.HTTPClient.Do(req)
+	return c.decodeBytes(b, output)
+}
+
+// decodeBody decodes the body of resp into output with the codec of the client.
+func (c *Client) decodeBody(resp *http.Response, output interface{}) error {
+	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
-	if o.Debug {
-		r, err := httputil.DumpResponse(resp, true)
-		if err != nil {
-			return err
-		}
-		log.Printf("INFO: received response\n%s\n", r)
+	return c.options.Codec.Unmarshal(b, output)
+}
+
+// decodeBytes decodes the JSON response body b into output.
+func (c *Client) decodeBytes(b []byte, output interface{}) error {
+	if err := c.options.Codec.Unmarshal(b, output); err != nil {
+		return err
 	}
-	keyID = ""
-	req = nil
-	if resp.StatusCode >= 400 {
-		sdkErr := Error{
-			StatusCode: resp.StatusCode,
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&sdkErr); err != nil {
-			return sdkErr
+	localizeTimes(output, c.options.Location)
+	return nil
+}
+
+// loadCredentials returns the candidate-th credentials to try, starting from the preferred ones, set with
+// SetCredentials and AddCredentials, or loaded with CredentialsLoaderFunc in which case shouldCleanMemory
+// is set as they must be cleaned from the memory after use, unless DisableCredentialsZeroing is set.
+func (c *Client) loadCredentials(candidate int) (keyID string, privateKeyPEM []byte, shouldCleanMemory bool, err error) {
+	if c.options.CredentialsLoaderFunc == nil {
+		keyID, privateKeyPEM, err = c.defaultCredentialsLoaderFunc(candidate)
+		return keyID, privateKeyPEM, false, err
+	}
+	shouldCleanMemory = !c.options.DisableCredentialsZeroing
+	keyID, privateKeyPEM, err = c.options.CredentialsLoaderFunc()
+	// the key is not signed with upon an error, clean it up from memory here. It is passed as the returned
+	// privateKeyPEM is nil by then.
+	defer func(loadedKeyPEM []byte) {
+		if err == nil || !shouldCleanMemory {
+			return
 		}
-		// rate-limited
-		if resp.StatusCode == http.StatusTooManyRequests {
-			i, err := strconv.ParseInt(resp.Header.Get("Retry-After"), 10, 64)
-			if err != nil {
-				return sdkErr
-			}
-			time.Sleep(time.Duration(i) * time.Second)
-			goto retry
+		for i := range loadedKeyPEM {
+			loadedKeyPEM[i] = 0
 		}
-		return sdkErr
+	}(privateKeyPEM)
+	if err != nil {
+		return "", nil, shouldCleanMemory, err
+	}
+	// a secret store miss would otherwise surface as a confusing signing error.
+	if keyID == "" {
+		return "", nil, shouldCleanMemory, Error{Code: ErrCredentialsNotSet, Message: "wallet: CredentialsLoaderFunc returned an empty key ID."}
+	}
+	if block, _ := pem.Decode(privateKeyPEM); block == nil {
+		return "", nil, shouldCleanMemory, Error{Code: ErrCredentialsNotSet, Message: "wallet: CredentialsLoaderFunc returned a private key that is not in PEM format."}
+	}
+	return keyID, privateKeyPEM, shouldCleanMemory, nil
+}
+
+// loadSigner returns the signer loaded with SignerFunc.
+func (c *Client) loadSigner(ctx context.Context) (keyID string, signer crypto.Signer, alg string, err error) {
+	keyID, signer, alg, err = c.options.SignerFunc(ctx)
+	if err != nil {
+		return "", nil, "", err
 	}
-	return json.NewDecoder(resp.Body).Decode(&output)
+	if keyID == "" {
+		return "", nil, "", Error{Code: ErrCredentialsNotSet, Message: "wallet: SignerFunc returned an empty key ID."}
+	}
+	if signer == nil {
+		return "", nil, "", Error{Code: ErrCredentialsNotSet, Message: "wallet: SignerFunc returned a nil signer."}
+	}
+	return keyID, signer, alg, nil
+}
+
+// credentialsCount returns the number of candidate credentials.
+func (c *Client) credentialsCount() int {
+	if c.options.CredentialsLoaderFunc != nil {
+		return 1
+	}
+	c.credentialsMu.Lock()
+	defer c.credentialsMu.Unlock()
+	return max(len(c.credentials), 1)
+}
+
+// preferCredentials makes the candidate-th credentials the first to try.
+func (c *Client) preferCredentials(candidate int) {
+	c.credentialsMu.Lock()
+	defer c.credentialsMu.Unlock()
+	if len(c.credentials) > 0 {
+		c.preferredCredentials = (c.preferredCredentials + candidate) % len(c.credentials)
+	}
+}
+
+func (c *Client) defaultCredentialsLoaderFunc(candidate int) (keyID string, privateKeyPEM []byte, err error) {
+	c.credentialsMu.Lock()
+	defer c.credentialsMu.Unlock()
+	if len(c.credentials) == 0 {
+		return "", nil, errCredentialsNotSet
+	}
+	creds := c.credentials[(c.preferredCredentials+candidate)%len(c.credentials)]
+	return creds.keyID, creds.privateKeyPEM, nil
 }
 
-func (c *Client) defaultCredentialsLoaderFunc() (keyID string, privateKeyPEM []byte, err error) {
-	if c.credentials == nil {
-		return "", nil, fmt.Errorf("credentials are not set. You may either use SetCredentials or provide CredentialsLoaderFunc upon client initialization.")
+// loadCachedSigner is like defaultCredentialsLoaderFunc but returns the parsed private key of the credentials,
+// parsing it upon the first call only, see Options.CacheParsedKeys.
+func (c *Client) loadCachedSigner(candidate int) (keyID string, signer crypto.Signer, alg string, err error) {
+	c.credentialsMu.Lock()
+	defer c.credentialsMu.Unlock()
+	if len(c.credentials) == 0 {
+		return "", nil, "", errCredentialsNotSet
+	}
+	creds := c.credentials[(c.preferredCredentials+candidate)%len(c.credentials)]
+	if creds.signer == nil {
+		creds.signer, creds.alg, err = parsePrivateKey(creds.privateKeyPEM)
+		if err != nil {
+			return "", nil, "", err
+		}
 	}
-	return c.credentials.keyID, c.credentials.privateKeyPEM, nil
+	return creds.keyID, creds.signer, creds.alg, nil
 }
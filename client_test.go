@@ -0,0 +1,555 @@
+package wallet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// testRequest is the decoded body of a request sent by the client.
+type testRequest struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func decodeTestRequest(t *testing.T, req *http.Request) testRequest {
+	t.Helper()
+	var r testRequest
+	if err := json.NewDecoder(req.Body).Decode(&r); err != nil {
+		t.Fatalf("failed to decode request body: %v", err)
+	}
+	return r
+}
+
+func jsonResponse(statusCode int, body any) *http.Response {
+	b, _ := json.Marshal(body)
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(b)),
+	}
+}
+
+func testECPrivateKeyPEM(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b})
+}
+
+// newTestClient returns a client with credentials set whose requests are served by rt.
+func newTestClient(t *testing.T, o *Options, rt roundTripFunc) *Client {
+	t.Helper()
+	if o == nil {
+		o = &Options{}
+	}
+	o.HTTPClient = &http.Client{Transport: rt}
+	c := New(o)
+	c.SetCredentials(testKeyID, testECPrivateKeyPEM(t))
+	return c
+}
+
+// decodeTestToken decodes the header and the payload of the JWT signing req.
+func decodeTestToken(t *testing.T, req *http.Request) (header map[string]any, payload map[string]any) {
+	t.Helper()
+	parts := strings.Split(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token %q", req.Header.Get("Authorization"))
+	}
+	for i, v := range []*map[string]any{&header, &payload} {
+		b, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return header, payload
+}
+
+// panicReader is a response body whose Read panics.
+type panicReader struct{}
+
+func (panicReader) Read(p []byte) (int, error) {
+	panic("malformed body")
+}
+
+func TestClientRecoversPanic(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(panicReader{}),
+		}, nil
+	})
+
+	_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInternal {
+		t.Fatalf("got %v, want %s", err, ErrInternal)
+	}
+	if !strings.Contains(werr.Message, "malformed body") {
+		t.Errorf("got message %q", werr.Message)
+	}
+
+	_, err = c.CreateClientBankAccount(context.Background(), &CreateClientBankAccountInput{BankAccount: &BankAccount{BankBic: "MBBEMYKL"}})
+	if !errors.As(err, &werr) || werr.Code != ErrInternal {
+		t.Fatalf("got %v, want %s", err, ErrInternal)
+	}
+}
+
+func TestClientUserAgent(t *testing.T) {
+	for _, tt := range []struct {
+		userAgent string
+		want      string
+	}{
+		{"", "halogen-go-sdk/" + Version},
+		{"acme-app/1.2.0", "acme-app/1.2.0"},
+	} {
+		var got string
+		c := newTestClient(t, &Options{UserAgent: tt.userAgent}, func(req *http.Request) (*http.Response, error) {
+			got = req.Header.Get("User-Agent")
+			return jsonResponse(http.StatusOK, map[string]any{}), nil
+		})
+		if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("got User-Agent %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestClientUnexpectedContentType(t *testing.T) {
+	page := "<html><head><title>502 Bad Gateway</title></head><body>" + strings.Repeat("nginx ", 100) + "</body></html>"
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadGateway,
+			Header:     http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+			Body:       io.NopCloser(strings.NewReader(page)),
+		}, nil
+	})
+
+	_, err := c.ListBanks(context.Background(), &ListBanksInput{}, WithoutRetry())
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrUnexpectedContentType {
+		t.Fatalf("got %v, want %s", err, ErrUnexpectedContentType)
+	}
+	if werr.StatusCode != http.StatusBadGateway {
+		t.Errorf("got status code %d, want %d", werr.StatusCode, http.StatusBadGateway)
+	}
+	if !strings.Contains(werr.Message, "502 Bad Gateway") || len(werr.Message) > 2*contentTypeSnippetSize {
+		t.Errorf("got message %q, want a truncated snippet of the page", werr.Message)
+	}
+}
+
+func TestClientCredentialsFallback(t *testing.T) {
+	var kids []string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		_, payload := decodeTestToken(t, req)
+		kid, _ := payload["kid"].(string)
+		kids = append(kids, kid)
+		if kid == "rotated-key" {
+			return jsonResponse(http.StatusUnauthorized, map[string]any{"code": ErrInvalidAuthSignature}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+	c.AddCredentials("rotated-key", testECPrivateKeyPEM(t))
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the most recently set key is tried first, and the second call prefers the key accepted by the first one.
+	want := []string{"rotated-key", testKeyID, testKeyID}
+	if strings.Join(kids, ",") != strings.Join(want, ",") {
+		t.Errorf("got keys %v, want %v", kids, want)
+	}
+}
+
+func TestClientCompressRequests(t *testing.T) {
+	var encoding string
+	var sent, decompressed []byte
+	var bodyHash any
+	c := newTestClient(t, &Options{CompressRequests: true}, func(req *http.Request) (*http.Response, error) {
+		encoding = req.Header.Get("Content-Encoding")
+		_, payload := decodeTestToken(t, req)
+		bodyHash = payload["bodyHash"]
+		sent, _ = io.ReadAll(req.Body)
+		decompressed = sent
+		if encoding == "gzip" {
+			r, err := gzip.NewReader(bytes.NewReader(sent))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decompressed, err = io.ReadAll(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+
+	for _, tt := range []struct {
+		size         int
+		wantEncoding string
+	}{
+		{10, ""},
+		{4 * compressionThreshold, "gzip"},
+	} {
+		input := map[string]string{"reference": strings.Repeat("a", tt.size)}
+		var output map[string]any
+		if err := c.command(context.Background(), "test", input, &output); err != nil {
+			t.Fatal(err)
+		}
+		if encoding != tt.wantEncoding {
+			t.Errorf("size %d: got Content-Encoding %q, want %q", tt.size, encoding, tt.wantEncoding)
+		}
+		if want, _ := c.encodeBody(commandInput{Name: "test", Payload: input}); !bytes.Equal(decompressed, want) {
+			t.Errorf("size %d: got body %.40s, want %.40s", tt.size, decompressed, want)
+		}
+		// the body hash is computed over the bytes sent.
+		if want := fmt.Sprintf("%x", sha256.Sum256(sent)); bodyHash != want {
+			t.Errorf("size %d: got bodyHash %v, want %s", tt.size, bodyHash, want)
+		}
+	}
+}
+
+func TestClientCredentialsLoaderEmpty(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		keyID         string
+		privateKeyPEM []byte
+	}{
+		{"empty key ID", "", testECPrivateKeyPEM(t)},
+		{"nil private key", testKeyID, nil},
+		{"undecodable private key", testKeyID, []byte("not a PEM")},
+	} {
+		sent := false
+		c := New(&Options{
+			HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				sent = true
+				return jsonResponse(http.StatusOK, map[string]any{}), nil
+			})},
+			CredentialsLoaderFunc: func() (string, []byte, error) {
+				return tt.keyID, tt.privateKeyPEM, nil
+			},
+		})
+		_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+		var werr Error
+		if !errors.As(err, &werr) || werr.Code != ErrCredentialsNotSet {
+			t.Errorf("%s: got %v, want %s", tt.name, err, ErrCredentialsNotSet)
+		}
+		if sent {
+			t.Errorf("%s: the request was sent", tt.name)
+		}
+	}
+}
+
+func TestClientCredentialsZeroing(t *testing.T) {
+	for _, tt := range []struct {
+		disable    bool
+		wantZeroed bool
+	}{
+		{false, true},
+		{true, false},
+	} {
+		key := testECPrivateKeyPEM(t)
+		c := New(&Options{
+			HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return jsonResponse(http.StatusOK, map[string]any{}), nil
+			})},
+			CredentialsLoaderFunc: func() (string, []byte, error) {
+				return testKeyID, key, nil
+			},
+			DisableCredentialsZeroing: tt.disable,
+		})
+		want := bytes.Clone(key)
+		if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+			t.Fatal(err)
+		}
+		if zeroed := !bytes.Equal(key, want); zeroed != tt.wantZeroed {
+			t.Errorf("DisableCredentialsZeroing %t: got key zeroed %t, want %t", tt.disable, zeroed, tt.wantZeroed)
+		}
+	}
+
+	// the key set with SetCredentials is never zeroed.
+	key := testECPrivateKeyPEM(t)
+	want := bytes.Clone(key)
+	c := New(&Options{HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})}})
+	c.SetCredentials(testKeyID, key)
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, want) {
+		t.Error("the key set with SetCredentials was zeroed")
+	}
+}
+
+func TestClientContextDeadline(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		rt   roundTripFunc
+	}{
+		{"slow response", func(req *http.Request) (*http.Response, error) {
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(5 * time.Second):
+				return jsonResponse(http.StatusOK, map[string]any{}), nil
+			}
+		}},
+		{"rate-limited", func(req *http.Request) (*http.Response, error) {
+			resp := jsonResponse(http.StatusTooManyRequests, map[string]any{"code": "rate_limited"})
+			resp.Header.Set("Retry-After", "5")
+			return resp, nil
+		}},
+	} {
+		c := newTestClient(t, nil, tt.rt)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		start := time.Now()
+		_, err := c.ListBanks(ctx, &ListBanksInput{})
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: returned after %s, want the context deadline to apply", tt.name, elapsed)
+		}
+	}
+}
+
+func TestClientUnauthorizedCodes(t *testing.T) {
+	for _, tt := range []struct {
+		serverCode string
+		want       string
+	}{
+		{"signature_body_mismatch", ErrBodyHashMismatch},
+		{"ErrInvalidAuthSignature", ErrInvalidAuthSignature},
+	} {
+		attempts := 0
+		c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return jsonResponse(http.StatusUnauthorized, map[string]any{"code": tt.serverCode, "message": "rejected"}), nil
+		})
+		_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+		var werr Error
+		if !errors.As(err, &werr) || werr.Code != tt.want || werr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: got %v, want %s", tt.serverCode, err, tt.want)
+		}
+		if attempts != 1 {
+			t.Errorf("%s: got %d attempts, want 1", tt.serverCode, attempts)
+		}
+	}
+
+	// a body hash mismatch is not caused by the credentials, the other candidates are not tried.
+	attempts := 0
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return jsonResponse(http.StatusUnauthorized, map[string]any{"code": "signature_body_mismatch"}), nil
+	})
+	c.AddCredentials("rotated-key", testECPrivateKeyPEM(t))
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err == nil || attempts != 1 {
+		t.Errorf("got %v after %d attempts, want %s after 1", err, attempts, ErrBodyHashMismatch)
+	}
+}
+
+func TestClientSlowRequest(t *testing.T) {
+	for _, tt := range []struct {
+		delay    time.Duration
+		wantSlow bool
+	}{
+		{0, false},
+		{50 * time.Millisecond, true},
+	} {
+		var logs bytes.Buffer
+		var operations []Operation
+		var durations []time.Duration
+		c := newTestClient(t, &Options{
+			Logger:               slog.New(slog.NewJSONHandler(&logs, nil)),
+			SlowRequestThreshold: 20 * time.Millisecond,
+			OnSlowRequest: func(operation Operation, duration time.Duration) {
+				operations = append(operations, operation)
+				durations = append(durations, duration)
+			},
+		}, func(req *http.Request) (*http.Response, error) {
+			time.Sleep(tt.delay)
+			return jsonResponse(http.StatusOK, map[string]any{}), nil
+		})
+		if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+			t.Fatal(err)
+		}
+		if !tt.wantSlow {
+			if len(operations) != 0 || logs.Len() != 0 {
+				t.Errorf("delay %s: got slow requests %v and logs %q, want none", tt.delay, operations, logs.String())
+			}
+			continue
+		}
+		if len(operations) != 1 || operations[0] != OperationListBanks || durations[0] < tt.delay {
+			t.Errorf("delay %s: got slow requests %v lasting %v, want %s", tt.delay, operations, durations, OperationListBanks)
+		}
+		var line map[string]any
+		if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+			t.Fatalf("got logs %q, want a single JSON line: %v", logs.String(), err)
+		}
+		if line["level"] != "WARN" || line["msg"] != "wallet: slow request" || line["operation"] != "list_banks" {
+			t.Errorf("got log %v", line)
+		}
+	}
+}
+
+func TestClientErrorContext(t *testing.T) {
+	c := newTestClient(t, &Options{RetryInterval: time.Millisecond}, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusInternalServerError, map[string]any{"code": ErrInternal, "message": "internal error"}), nil
+	})
+	_, err := c.ListClientAccounts(context.Background(), &ListClientAccountsInput{})
+	if err == nil || err.Error() != "wallet: operation=list_client_accounts attempt=5: internal error" {
+		t.Errorf("got error %v, want the operation and the last attempt", err)
+	}
+	// the error is still an Error.
+	werr, ok := err.(Error)
+	if !ok || werr.Code != ErrInternal || werr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("got error %#v, want the %s error preserved", err, ErrInternal)
+	}
+	if werr.Operation != OperationListClientAccounts || werr.Attempt != 5 || werr.Message != "internal error" {
+		t.Errorf("got operation %s, attempt %d and message %q", werr.Operation, werr.Attempt, werr.Message)
+	}
+
+	// other errors are wrapped.
+	c = newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return nil, io.ErrUnexpectedEOF
+	})
+	_, err = c.ListClientAccounts(context.Background(), &ListClientAccountsInput{}, WithoutRetry())
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !strings.Contains(err.Error(), "wallet: operation=list_client_accounts attempt=1: ") {
+		t.Errorf("got error %v, want %v with the operation and the attempt", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestPreferredAlgorithm(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+
+	for _, tt := range []struct {
+		preferred string
+		want      []string
+	}{
+		// the RSA key is the most recently set.
+		{"", []string{"rsa-key:RS256"}},
+		// the EC key is rejected, the RSA key is the fallback.
+		{es256, []string{"ec-key:ES256", "rsa-key:RS256"}},
+	} {
+		var tried []string
+		c := newTestClient(t, &Options{PreferredAlgorithm: tt.preferred}, func(req *http.Request) (*http.Response, error) {
+			header, payload := decodeTestToken(t, req)
+			kid, _ := payload["kid"].(string)
+			tried = append(tried, fmt.Sprintf("%s:%s", kid, header["alg"]))
+			if kid == "ec-key" {
+				return jsonResponse(http.StatusUnauthorized, map[string]any{"code": ErrInvalidAuthSignature}), nil
+			}
+			return jsonResponse(http.StatusOK, map[string]any{}), nil
+		})
+		c.SetCredentials("ec-key", testECPrivateKeyPEM(t))
+		c.AddCredentials("rsa-key", rsaPEM)
+		if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(tried, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("preferred %q: got keys %v, want %v", tt.preferred, tried, tt.want)
+		}
+	}
+
+	var werr Error
+	if _, err := NewWithOptions(&Options{PreferredAlgorithm: "HS256"}); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got error %v for an unknown PreferredAlgorithm, want %s", err, ErrInvalidParameter)
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	c, err := NewWithOptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.options.UserAgent != userAgent || c.options.IdempotencyStore == nil {
+		t.Errorf("got options %+v, want the defaults", c.options)
+	}
+
+	o := &Options{ResponsePublicKeyPEM: []byte("not a key")}
+	var werr Error
+	if c, err := NewWithOptions(o); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter || c != nil {
+		t.Errorf("got client %v and error %v, want no client and %s", c, err, ErrInvalidParameter)
+	}
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	var logs bytes.Buffer
+	var header, payload map[string]any
+	rt := func(req *http.Request) (*http.Response, error) {
+		header, payload = decodeTestToken(t, req)
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	}
+
+	// invalid options are logged and defaulted.
+	o := &Options{
+		Logger:             slog.New(slog.NewTextHandler(&logs, nil)),
+		NonceBytes:         8,
+		TokenType:          " ",
+		PreferredAlgorithm: "HS256",
+		ExtraClaims:        map[string]any{"sub": "x", "tenant": "acme"},
+	}
+	c := newTestClient(t, o, rt)
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if header["typ"] != "JWT" || len(payload["nonce"].(string)) != 2*defaultNonceBytes || payload["sub"] != "wallet" || payload["tenant"] != "acme" {
+		t.Errorf("got header %v and payload %v, want the defaults of the invalid options", header, payload)
+	}
+	if got := strings.Count(logs.String(), "ignoring an invalid option"); got != 4 {
+		t.Errorf("got %d invalid options logged, want 4:\n%s", got, logs.String())
+	}
+
+	// an invalid response key fails the requests rather than leaving the responses unverified.
+	logs.Reset()
+	c = newTestClient(t, &Options{Logger: slog.New(slog.NewTextHandler(&logs, nil)), ResponsePublicKeyPEM: []byte("not a key")}, rt)
+	var werr Error
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got %v, want %s", err, ErrInvalidParameter)
+	}
+	if !strings.Contains(logs.String(), "failsRequests=true") {
+		t.Errorf("got logs %s, want the invalid response key logged", logs.String())
+	}
+}
@@ -0,0 +1,28 @@
+package wallet
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Codec encodes the bodies of requests and decodes the bodies of responses, see [Options.Codec].
+// Implementations must be safe for concurrent use and produce JSON as the server expects.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// jsonCodec is the default [Codec], based on [encoding/json].
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// indentCodec is a [Codec] indenting the JSON it encodes, recording its use.
+type indentCodec struct {
+	marshaled, unmarshaled int
+}
+
+func (c *indentCodec) Marshal(v any) ([]byte, error) {
+	c.marshaled++
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func (c *indentCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshaled++
+	return json.Unmarshal(data, v)
+}
+
+func TestCodec(t *testing.T) {
+	codec := &indentCodec{}
+	var body []byte
+	var bodyHash any
+	c := newTestClient(t, &Options{Codec: codec}, func(req *http.Request) (*http.Response, error) {
+		_, payload := decodeTestToken(t, req)
+		bodyHash = payload["bodyHash"]
+		body, _ = io.ReadAll(req.Body)
+		return jsonResponse(http.StatusOK, map[string]any{"banks": []map[string]any{{"name": "Maybank", "bic": "MBBEMYKL"}}}), nil
+	})
+
+	output, err := c.ListBanks(context.Background(), &ListBanksInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codec.marshaled != 1 || codec.unmarshaled != 1 {
+		t.Errorf("got %d marshals and %d unmarshals, want 1 each", codec.marshaled, codec.unmarshaled)
+	}
+	if !strings.Contains(string(body), "\n  \"name\": \"list_banks\"") {
+		t.Errorf("got body %s, want the codec output", body)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256(body)); bodyHash != want {
+		t.Errorf("got bodyHash %v, want %s", bodyHash, want)
+	}
+	if len(output.Banks) != 1 || output.Banks[0].Bic != "MBBEMYKL" {
+		t.Errorf("got banks %+v", output.Banks)
+	}
+}
@@ -0,0 +1,51 @@
+package wallet
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// semaphore limits the number of requests in flight, see [Options.MaxConcurrentRequests].
+// A nil semaphore is unlimited.
+type semaphore chan struct{}
+
+// newSemaphore returns a semaphore of n slots, or nil when n is not positive.
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+// acquire blocks until a slot is available or ctx is done.
+func (s semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release releases a slot acquired with acquire.
+func (s semaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// releaseOnClose is a response body releasing the slot of its request when closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	defer b.once.Do(b.release)
+	return b.ReadCloser.Close()
+}
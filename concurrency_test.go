@@ -0,0 +1,98 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	const limit = 3
+	var inflight, peak atomic.Int32
+	c := newTestClient(t, &Options{MaxConcurrentRequests: limit}, func(req *http.Request) (*http.Response, error) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return jsonResponse(http.StatusOK, map[string]any{"banks": []map[string]any{}}), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4*limit; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := peak.Load(); got != limit {
+		t.Errorf("got %d requests in flight at most, want %d", got, limit)
+	}
+	if got := len(c.inflight); got != 0 {
+		t.Errorf("got %d slots held after the calls, want 0", got)
+	}
+}
+
+func TestMaxConcurrentRequestsContext(t *testing.T) {
+	unblock := make(chan struct{})
+	started := make(chan struct{})
+	c := newTestClient(t, &Options{MaxConcurrentRequests: 1}, func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-unblock
+		return jsonResponse(http.StatusOK, map[string]any{"banks": []map[string]any{}}), nil
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+		done <- err
+	}()
+	<-started
+
+	// the only slot is held, the call waits until its context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaxConcurrentRequestsDebug(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	c := newTestClient(t, &Options{MaxConcurrentRequests: 1, Debug: true}, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, map[string]any{"banks": []map[string]any{}}), nil
+	})
+
+	resp, err := c.queryRaw(context.Background(), OperationListBanks, &ListBanksInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the dumped body is still unread, the slot is held until it is closed.
+	if got := len(c.inflight); got != 1 {
+		t.Errorf("got %d slots held before closing the body, want 1", got)
+	}
+	resp.Body.Close()
+	if got := len(c.inflight); got != 0 {
+		t.Errorf("got %d slots held after closing the body, want 0", got)
+	}
+}
@@ -0,0 +1,18 @@
+package wallet
+
+import "context"
+
+type displayCurrencyKey struct{}
+
+// WithDisplayCurrency returns a copy of ctx carrying currency, for instance, "USD", as the display currency of the
+// queries made with it that accept one, such as [Client.ListClientAccountBalance], when their input is nil or does not
+// specify one.
+func WithDisplayCurrency(ctx context.Context, currency string) context.Context {
+	return context.WithValue(ctx, displayCurrencyKey{}, currency)
+}
+
+// displayCurrency returns the display currency carried by ctx, if any.
+func displayCurrency(ctx context.Context) string {
+	currency, _ := ctx.Value(displayCurrencyKey{}).(string)
+	return currency
+}
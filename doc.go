@@ -8,7 +8,8 @@
 // The JWT token is constructed with the following Payload fields
 //
 //   - `kid`: Key identifier (the Key ID returned by Halogen Wallet settings)
-//   - `sub`: Subject — currently set to the fixed value `"wallet"`
+//   - `sub`: Subject — set to `"wallet"` unless [Options.Subject] is set
+//   - `aud`: Audience — set to [Options.Audience], omitted when empty
 //   - `iat`: Issued At (Unix timestamp)
 //   - `exp`: Expiration (Unix timestamp) — set to `iat + ttl` where `ttl` is the token lifetime the client uses
 //   - `nonce`: A random hex string used to prevent replay attacks
@@ -97,7 +98,7 @@
 //		log.Printf("Fund NAV: %f %s\n", price.NetAssetValuePerUnit, price.Asset)
 //
 //		// Create an investment request
-//		investmentAmount := 10000.0
+//		investmentAmount := wallet.NewAmount(10000, "MYR")
 //		investReq, err := client.CreateInvestmentRequest(ctx, &wallet.CreateInvestmentRequestInput{
 //			AccountID:         accountID,
 //			FundID:            fundID,
@@ -120,12 +121,22 @@
 //
 // - [Client.GetFund]
 //
+// - [Client.GetFunds]
+//
 // - [Client.GetClientAccountAllocationPerformance]
 //
 // - [Client.GetClientAccountStatement]
 //
+// - [Client.WriteClientAccountStatementCSV]
+//
+// - [Client.StreamClientAccountStatement]
+//
+// - [Client.ExportStatementCSV]
+//
 // - [Client.GetClientAccountRequestConfirmation]
 //
+// - [Client.DownloadConfirmations]
+//
 // - [Client.GetClientReferral]
 //
 // - [Client.GetClientAccountRequestPolicy]
@@ -134,8 +145,14 @@
 //
 // - [Client.ListClientAccountBalance]
 //
+// - [Client.ListClientAccountBalances]
+//
 // - [Client.ListClientAccountRequests]
 //
+// - [Client.WaitForRequest]
+//
+// - [Client.ListClientAccountMandateRequests]
+//
 // - [Client.ListClientBankAccounts]
 //
 // - [Client.ListDisplayCurrencies]
@@ -146,6 +163,8 @@
 //
 // - [Client.ListBanks]
 //
+// - [Client.ListDuitNowBanks]
+//
 // - [Client.ListClientPromos]
 //
 // - [Client.ListClientAccountPerformance]
@@ -158,6 +177,18 @@
 //
 // - [Client.GetProjectedFundPrice]
 //
+// - [Client.GetProjectedFundPrices]
+//
+// - [Client.GetJointInvitationStatus]
+//
+// - [Client.GetGoalProjection]
+//
+// - [Client.GetRequestByDuitNowEndToEndID]
+//
+// - [Client.VerifyDuitNowSettlement]
+//
+// - [Client.GetAuthorizedOperations]
+//
 // # Command APIs
 //
 // - [Client.CreateInvestmentRequest]
@@ -177,4 +208,8 @@
 // - [Client.UpdateAccountName]
 //
 // - [Client.UpdateClientProfile]
+//
+// - [Client.InviteCoHolder]
+//
+// - [Client.CreateDuitnowPayment]
 package wallet
@@ -1,5 +1,10 @@
 package wallet
 
+import (
+	"fmt"
+	"time"
+)
+
 const (
 	// Error codes returned by the Wallet SDK
 	//
@@ -7,6 +12,12 @@ const (
 	// AUTHENTICATION & AUTHORIZATION
 	// ================================
 	//
+	// ErrBodyHashMismatch is returned when the bodyHash claim of the request token does not match the body received
+	// by the server, meaning the body was altered after signing, for instance by a middleware. Unlike
+	// ErrInvalidAuthSignature, it does not mean the credentials are invalid. The server reports it with the
+	// signature_body_mismatch code. See [Options.VerifyBodyHash].
+	ErrBodyHashMismatch string = "ErrBodyHashMismatch"
+
 	// ErrExpiredApiKey is returned when the API key used in the request has expired.
 	ErrExpiredApiKey string = "ErrExpiredApiKey"
 
@@ -116,6 +127,10 @@ const (
 	// ErrRequestCannotBeCancelled is returned when the request cannot be cancelled due to its current state or business rules.
 	ErrRequestCannotBeCancelled string = "ErrRequestCannotBeCancelled"
 
+	// ErrRequestNotCancellable is an alias of [ErrRequestCannotBeCancelled], returned for instance when the request
+	// is past its dealing cutoff.
+	ErrRequestNotCancellable string = ErrRequestCannotBeCancelled
+
 	// ErrSuitabilityAssessmentMissingForAccountCreation is returned when a suitability assessment is required but missing during account creation.
 	ErrSuitabilityAssessmentMissingForAccountCreation string = "ErrSuitabilityAssessmentMissingForAccountCreation"
 
@@ -141,14 +156,77 @@ const (
 
 	// ErrServiceUnavailable is returned when a 3rd-party service is temporarily unavailable; try again later.
 	ErrServiceUnavailable string = "ErrServiceUnavailable"
+
+	// ErrMaintenance is returned with status code 503 when the API is under scheduled maintenance, until
+	// [Error.Until]. It is not retried.
+	ErrMaintenance string = "maintenance"
+
+	// ================================
+	// CLIENT
+	// ================================
+	//
+	// Errors below are raised by the client itself without the server being involved.
+	//
+	// ErrRetryBudgetExhausted is returned when a request fails with a retryable error but the client's retry budget
+	// is depleted, see [Options.RetryBudget].
+	ErrRetryBudgetExhausted string = "ErrRetryBudgetExhausted"
+
+	// ErrCredentialsNotSet is returned when no credentials are set with [Client.SetCredentials], or when
+	// [Options.CredentialsLoaderFunc] returns an empty key ID or a private key that is not PEM encoded.
+	ErrCredentialsNotSet string = "ErrCredentialsNotSet"
+
+	// ErrInvalidPrivateKey is returned by [Client.ValidateCredentials] when the private key cannot be parsed or used to sign,
+	// and by [PublicKeyJWK] and [PublicKeyPEM] when it cannot be parsed.
+	ErrInvalidPrivateKey string = "ErrInvalidPrivateKey"
+
+	// ErrCurrencyMismatch is returned when adding or subtracting [Amount] values in different currencies.
+	ErrCurrencyMismatch string = "ErrCurrencyMismatch"
+
+	// ErrSettlementMismatch is returned by [Client.VerifyDuitNowSettlement] when the amount or the currency of the
+	// request funded by the payment differs from the expected one.
+	ErrSettlementMismatch string = "ErrSettlementMismatch"
+
+	// ErrResponseTooLarge is returned when the body of a response exceeds [Options.MaxResponseBytes], or the limit
+	// of the call set with [WithMaxResponseBytes], and by [GetClientAccountRequestConfirmationOutput.Download] when
+	// the document exceeds 32 MiB.
+	ErrResponseTooLarge string = "ErrResponseTooLarge"
+
+	// ErrUnexpectedContentType is returned when the server responds with a body that is not JSON, for instance,
+	// an HTML error page from a gateway. The message includes the beginning of the body.
+	ErrUnexpectedContentType string = "ErrUnexpectedContentType"
+
+	// ErrResponseSignatureInvalid is returned when the signature of a response is missing or does not match its body,
+	// see [Options.ResponsePublicKeyPEM] and [Options.JWKSURL].
+	ErrResponseSignatureInvalid string = "ErrResponseSignatureInvalid"
+
+	// ErrJWKSUnavailable is returned when the JWKS verifying the signature of a response cannot be fetched from
+	// [Options.JWKSURL], or is invalid or too large.
+	ErrJWKSUnavailable string = "ErrJWKSUnavailable"
 )
 
+// serverCodeBodyHashMismatch is the code of the 401 responses returned by the server when the body it received
+// does not match the bodyHash claim, reported as ErrBodyHashMismatch.
+const serverCodeBodyHashMismatch string = "signature_body_mismatch"
+
+// Error represents an error returned by the server, or raised by the client with the codes above. The Error of a
+// call sending a request tells the operation and the attempt producing it, for instance,
+// "wallet: operation=list_client_accounts attempt=3: <message>". Other errors of such a call, for instance, a
+// transport error, are wrapped with the same context, use [errors.Is] and [errors.As] to inspect them.
 type Error struct {
 	StatusCode int    `json:"statusCode"`
 	Code       string `json:"code"`
 	Message    string `json:"message"`
+	// Until specifies when the maintenance ends for [ErrMaintenance], it is zero otherwise.
+	Until time.Time `json:"until,omitzero"`
+	// Operation specifies the operation of the request producing the error, empty when raised before sending it.
+	Operation Operation `json:"operation,omitempty"`
+	// Attempt specifies the attempt producing the error, from 1, zero when raised before sending the request.
+	Attempt int `json:"attempt,omitempty"`
 }
 
 func (e Error) Error() string {
-	return e.Message
+	if e.Operation == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("wallet: operation=%s attempt=%d: %s", e.Operation, e.Attempt, e.Message)
 }
@@ -0,0 +1,77 @@
+package wallet
+
+import (
+	"sync"
+	"time"
+)
+
+// fundCache caches the funds retrieved with [Client.GetFund], each for [Options.FundCacheTTL] from
+// when it was retrieved.
+type fundCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]fundCacheEntry
+}
+
+type fundCacheEntry struct {
+	fund      Fund
+	expiresAt time.Time
+}
+
+// newFundCache returns a cache of funds kept for ttl, or nil when ttl is not positive.
+func newFundCache(ttl time.Duration) *fundCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &fundCache{ttl: ttl, entries: map[string]fundCacheEntry{}}
+}
+
+// get returns a copy of the cached fund fundID. A nil cache caches nothing.
+func (c *fundCache) get(fundID string) (*Fund, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[fundID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, fundID)
+		return nil, false
+	}
+	fund := entry.fund
+	return &fund, true
+}
+
+func (c *fundCache) set(fundID string, fund *Fund) {
+	if c == nil || fund == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[fundID] = fundCacheEntry{fund: *fund, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// invalidate removes the cached funds fundIDs, or all of them when none is given.
+func (c *fundCache) invalidate(fundIDs ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(fundIDs) == 0 {
+		clear(c.entries)
+		return
+	}
+	for _, fundID := range fundIDs {
+		delete(c.entries, fundID)
+	}
+}
+
+// InvalidateFunds removes the funds fundIDs cached by the client, or all of them when none is given, for
+// instance, when their NAV is updated. See [Options.FundCacheTTL].
+func (c *Client) InvalidateFunds(fundIDs ...string) {
+	c.funds.invalidate(fundIDs...)
+}
@@ -0,0 +1,129 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newFundClient returns a client caching funds for ttl and the requested fund IDs, in the order they were sent.
+func newFundClient(t *testing.T, ttl time.Duration) (*Client, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requested []string
+	c := newTestClient(t, &Options{FundCacheTTL: ttl}, func(req *http.Request) (*http.Response, error) {
+		var input GetFundInput
+		if err := json.Unmarshal(decodeTestRequest(t, req).Payload, &input); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		requested = append(requested, input.FundID)
+		mu.Unlock()
+		if input.FundID == "fund_missing" {
+			return jsonResponse(http.StatusNotFound, map[string]any{"code": ErrMissingResource}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"fund": map[string]any{"id": input.FundID, "name": "Fund " + input.FundID}}), nil
+	})
+	return c, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requested...)
+	}
+}
+
+func TestGetFunds(t *testing.T) {
+	c, requested := newFundClient(t, time.Minute)
+	result := c.GetFunds(context.Background(), []string{"fund_a", "fund_missing", "fund_b"})
+	if len(result.Succeeded) != 2 || result.Succeeded["fund_a"].ID != "fund_a" || result.Succeeded["fund_b"].Name != "Fund fund_b" {
+		t.Errorf("got funds %v, want fund_a and fund_b", result.Succeeded)
+	}
+	var werr Error
+	if len(result.Failed) != 1 || !errors.As(result.Failed["fund_missing"], &werr) || werr.Code != ErrMissingResource {
+		t.Errorf("got errors %v, want %s for fund_missing", result.Failed, ErrMissingResource)
+	}
+	if got := len(requested()); got != 3 {
+		t.Errorf("sent %d requests, want 3", got)
+	}
+
+	// the funds retrieved are cached, failures are not.
+	result = c.GetFunds(context.Background(), []string{"fund_a", "fund_b", "fund_missing"})
+	if len(result.Succeeded) != 2 || len(result.Failed) != 1 {
+		t.Errorf("got funds %v and errors %v", result.Succeeded, result.Failed)
+	}
+	if got := requested(); len(got) != 4 || got[3] != "fund_missing" {
+		t.Errorf("got requests %v, want fund_missing only requested again", got)
+	}
+}
+
+func TestGetFundCache(t *testing.T) {
+	c, requested := newFundClient(t, time.Minute)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		output, err := c.GetFund(ctx, &GetFundInput{FundID: "fund_a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output.Fund == nil || output.Fund.ID != "fund_a" {
+			t.Fatalf("got output %+v", output)
+		}
+		// the cached fund is not altered by callers.
+		output.Fund.Name = "altered"
+	}
+	if got := requested(); len(got) != 1 {
+		t.Errorf("got requests %v, want one", got)
+	}
+	if _, err := c.GetFund(ctx, &GetFundInput{FundID: "fund_b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a NAV update invalidates its fund only.
+	c.InvalidateFunds("fund_a")
+	output, err := c.GetFund(ctx, &GetFundInput{FundID: "fund_a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Fund.Name != "Fund fund_a" {
+		t.Errorf("got name %q, want the fund refreshed", output.Fund.Name)
+	}
+	if _, err := c.GetFund(ctx, &GetFundInput{FundID: "fund_b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := requested(); len(got) != 3 {
+		t.Errorf("got requests %v, want fund_a, fund_b and fund_a", got)
+	}
+
+	c.InvalidateCache()
+	if _, err := c.GetFund(ctx, &GetFundInput{FundID: "fund_b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := requested(); len(got) != 4 {
+		t.Errorf("got requests %v, want fund_b requested again", got)
+	}
+}
+
+func TestGetFundCacheExpiry(t *testing.T) {
+	c, requested := newFundClient(t, 100*time.Millisecond)
+	ctx := context.Background()
+	if _, err := c.GetFund(ctx, &GetFundInput{FundID: "fund_a"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, err := c.GetFund(ctx, &GetFundInput{FundID: "fund_b"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	// fund_a expired while fund_b, retrieved later, is still cached.
+	for _, fundID := range []string{"fund_a", "fund_b"} {
+		if _, err := c.GetFund(ctx, &GetFundInput{FundID: fundID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := requested(); len(got) != 3 || got[2] != "fund_a" {
+		t.Errorf("got requests %v, want fund_a requested again only", got)
+	}
+}
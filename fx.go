@@ -0,0 +1,30 @@
+package wallet
+
+import "math"
+
+// FxRates holds the exchange rates of currencies against a common base currency, for instance,
+// FxRates{"MYR": 1, "USD": 0.2125} where 1 MYR is worth 0.2125 USD. The server does not provide
+// exchange rates, they are supplied by the caller.
+type FxRates map[string]float64
+
+// Convert converts amount from the currency from to the currency to, for instance, an amount returned in the
+// base asset of an account to the display currency. The result is rounded half away from zero to 2 decimals,
+// as amounts returned by the server, so converting to the same currency returns the amount rounded.
+//
+// It returns [ErrInvalidParameter] when the rate of from or to is missing or not positive.
+func (r FxRates) Convert(amount float64, from string, to string) (float64, error) {
+	fromRate, ok := r[from]
+	if !ok || fromRate <= 0 {
+		return 0, Error{Code: ErrInvalidParameter, Message: "wallet: no exchange rate for " + from + "."}
+	}
+	toRate, ok := r[to]
+	if !ok || toRate <= 0 {
+		return 0, Error{Code: ErrInvalidParameter, Message: "wallet: no exchange rate for " + to + "."}
+	}
+	return roundAmount(amount / fromRate * toRate), nil
+}
+
+// roundAmount rounds amount half away from zero to 2 decimals.
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
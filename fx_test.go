@@ -0,0 +1,42 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFxRatesConvert(t *testing.T) {
+	rates := FxRates{"MYR": 1, "USD": 0.2125, "SGD": 0.2875, "XXX": 0}
+	for _, tt := range []struct {
+		amount   float64
+		from, to string
+		want     float64
+		wantCode string
+	}{
+		{1000, "MYR", "USD", 212.5, ""},
+		{212.5, "USD", "MYR", 1000, ""},
+		{100, "USD", "SGD", 135.29, ""},
+		// 0.125 MYR is 0.0265625 USD, rounded half away from zero.
+		{0.125, "MYR", "USD", 0.03, ""},
+		{-0.125, "MYR", "USD", -0.03, ""},
+		{10.005, "MYR", "MYR", 10.01, ""},
+		{100, "MYR", "EUR", 0, ErrInvalidParameter},
+		{100, "XXX", "MYR", 0, ErrInvalidParameter},
+	} {
+		got, err := rates.Convert(tt.amount, tt.from, tt.to)
+		if tt.wantCode != "" {
+			var werr Error
+			if !errors.As(err, &werr) || werr.Code != tt.wantCode {
+				t.Errorf("%v %s to %s: got %v, want %s", tt.amount, tt.from, tt.to, err, tt.wantCode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v %s to %s: %v", tt.amount, tt.from, tt.to, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v %s to %s: got %v, want %v", tt.amount, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultIdempotencyKeyTTL specifies how long the idempotency key of a command is reused by default,
+// see Options.IdempotencyKeyTTL.
+const defaultIdempotencyKeyTTL = time.Hour
+
+// IdempotencyStore persists the idempotency keys of commands, so a command retried after a crash
+// or a restart is sent with the same key and the server processes it at most once.
+//
+// A command is identified by its fingerprint, the hex-encoded SHA-256 of its request body. The client
+// loads the key of the fingerprint before sending the command, generates and stores a new key when
+// none exists, and deletes it once the server responded definitively, that is, with a success or a
+// client error.
+//
+// A key left behind, for instance, after a server error, a transport error or a cancelled context,
+// must expire after the TTL passed to Store, see [Options.IdempotencyKeyTTL]. Otherwise, the same
+// command sent deliberately later, such as a recurring investment, would reuse the key and be
+// deduplicated by the server.
+//
+// Use a durable implementation to survive process restarts. For instance, a file-backed store may
+// keep one file per fingerprint in a directory along with its expiry, and a Redis-backed store may
+// use SET fingerprint key PX <ttl> NX, GET fingerprint and DEL fingerprint.
+type IdempotencyStore interface {
+	// Load returns the idempotency key stored for fingerprint. ok is false when none is stored.
+	Load(ctx context.Context, fingerprint string) (key string, ok bool, err error)
+	// Store persists key for fingerprint, for ttl at most.
+	Store(ctx context.Context, fingerprint string, key string, ttl time.Duration) error
+	// Delete removes the key stored for fingerprint.
+	Delete(ctx context.Context, fingerprint string) error
+}
+
+// MemoryIdempotencyStore is an [IdempotencyStore] keeping keys in memory. Keys survive retries
+// within the process and can be shared by clients of the same process, but not across restarts.
+//
+// It is the default store of a client.
+type MemoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]idempotencyKey
+}
+
+// idempotencyKey is a key stored by [MemoryIdempotencyStore].
+type idempotencyKey struct {
+	key       string
+	expiresAt time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{keys: map[string]idempotencyKey{}}
+}
+
+func (s *MemoryIdempotencyStore) Load(ctx context.Context, fingerprint string) (key string, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[fingerprint]
+	if !ok || time.Now().After(k.expiresAt) {
+		return "", false, nil
+	}
+	return k.key, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Store(ctx context.Context, fingerprint string, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// the keys left behind expire, evict them so the store does not grow unbounded.
+	for fingerprint, k := range s.keys {
+		if now.After(k.expiresAt) {
+			delete(s.keys, fingerprint)
+		}
+	}
+	s.keys[fingerprint] = idempotencyKey{key: key, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Delete(ctx context.Context, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, fingerprint)
+	return nil
+}
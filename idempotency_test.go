@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeyReusedAcrossClients(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	input := &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1", FundClassSequence: 1, Amount: NewAmount(1000, "MYR")}
+
+	// the first client crashes, simulated by a server error, before the command is acknowledged.
+	var firstKey string
+	first := newTestClient(t, &Options{IdempotencyStore: store}, func(req *http.Request) (*http.Response, error) {
+		firstKey = req.Header.Get("Idempotency-Key")
+		return jsonResponse(http.StatusBadGateway, map[string]any{"code": ErrServiceUnavailable}), nil
+	})
+	if _, err := first.CreateInvestmentRequest(context.Background(), input); err == nil {
+		t.Fatal("expected an error")
+	}
+	if firstKey == "" {
+		t.Fatal("expected an idempotency key")
+	}
+
+	// the second client retries the same command.
+	var keys []string
+	second := newTestClient(t, &Options{IdempotencyStore: store}, func(req *http.Request) (*http.Response, error) {
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		return jsonResponse(http.StatusOK, map[string]any{"requestId": "req_1"}), nil
+	})
+	if _, err := second.CreateInvestmentRequest(context.Background(), input); err != nil {
+		t.Fatal(err)
+	}
+	if keys[0] != firstKey {
+		t.Errorf("got key %q, want %q", keys[0], firstKey)
+	}
+
+	// once acknowledged, the same command is a new logical command.
+	if _, err := second.CreateInvestmentRequest(context.Background(), input); err != nil {
+		t.Fatal(err)
+	}
+	if keys[1] == firstKey {
+		t.Errorf("expected a new key once the command was acknowledged")
+	}
+}
+
+func TestCommandRetriesTransportErrorOnce(t *testing.T) {
+	input := &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1", FundClassSequence: 1, Amount: NewAmount(1000, "MYR")}
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	resetErr := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	for _, tt := range []struct {
+		name      string
+		responses []func() (*http.Response, error)
+		wantKeys  int
+		wantErr   bool
+	}{
+		{"dial error", []func() (*http.Response, error){
+			func() (*http.Response, error) { return nil, dialErr },
+			func() (*http.Response, error) {
+				return jsonResponse(http.StatusOK, map[string]any{"requestId": "req_1"}), nil
+			},
+		}, 2, false},
+		{"connection reset twice", []func() (*http.Response, error){
+			func() (*http.Response, error) { return nil, resetErr },
+			func() (*http.Response, error) { return nil, resetErr },
+		}, 2, true},
+		{"server error", []func() (*http.Response, error){
+			func() (*http.Response, error) {
+				return jsonResponse(http.StatusInternalServerError, map[string]any{"code": ErrInternal}), nil
+			},
+		}, 1, true},
+	} {
+		var keys []string
+		c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+			keys = append(keys, req.Header.Get("Idempotency-Key"))
+			if len(keys) > len(tt.responses) {
+				t.Fatalf("%s: unexpected attempt %d", tt.name, len(keys))
+			}
+			return tt.responses[len(keys)-1]()
+		})
+		_, err := c.CreateInvestmentRequest(context.Background(), input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %t", tt.name, err, tt.wantErr)
+		}
+		if len(keys) != tt.wantKeys {
+			t.Errorf("%s: got %d attempts, want %d", tt.name, len(keys), tt.wantKeys)
+		}
+		for _, key := range keys {
+			if key == "" || key != keys[0] {
+				t.Errorf("%s: got keys %v, want the same key", tt.name, keys)
+			}
+		}
+		// the error response is returned as is.
+		var werr Error
+		if tt.name == "server error" && (!errors.As(err, &werr) || werr.Code != ErrInternal) {
+			t.Errorf("%s: got %v, want %s", tt.name, err, ErrInternal)
+		}
+	}
+}
+
+func TestRetryReusesBodyAndToken(t *testing.T) {
+	input := &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1", FundClassSequence: 1, Amount: NewAmount(1000, "MYR")}
+	var bodies, tokens []string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, string(body))
+		tokens = append(tokens, req.Header.Get("Authorization"))
+		if len(bodies) == 1 {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"requestId": "req_1"}), nil
+	})
+	if _, err := c.CreateInvestmentRequest(context.Background(), input); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(bodies))
+	}
+	if bodies[0] == "" || bodies[0] != bodies[1] {
+		t.Errorf("got bodies %q, want identical bodies", bodies)
+	}
+	if tokens[0] == "" || tokens[0] != tokens[1] {
+		t.Errorf("got tokens %q, want the same signed token", tokens)
+	}
+}
+
+func TestIdempotencyKeyExpires(t *testing.T) {
+	input := &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1", FundClassSequence: 1, Amount: NewAmount(1000, "MYR")}
+	var keys []string
+	c := newTestClient(t, &Options{IdempotencyKeyTTL: 20 * time.Millisecond}, func(req *http.Request) (*http.Response, error) {
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		return jsonResponse(http.StatusBadGateway, map[string]any{"code": ErrServiceUnavailable}), nil
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := c.CreateInvestmentRequest(context.Background(), input); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	// the key left behind by the server errors expires, the same command sent later is a new one.
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.CreateInvestmentRequest(context.Background(), input); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(keys) != 3 || keys[0] != keys[1] || keys[2] == keys[0] {
+		t.Errorf("got keys %v, want the first reused once then a new one", keys)
+	}
+}
@@ -4,26 +4,41 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	_ "crypto/sha512"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"math/big"
 	"time"
 )
 
 const (
 	es256 string = "ES256"
+	es384 string = "ES384"
+	es512 string = "ES512"
 	rs256 string = "RS256"
 )
 
+const (
+	// defaultNonceBytes is the default number of random bytes of the nonce claim, see Options.NonceBytes.
+	defaultNonceBytes = 20
+	// minNonceBytes is the minimum number of random bytes of the nonce claim for it to be unguessable.
+	minNonceBytes = 16
+	// defaultTokenType is the default typ header of the token, see Options.TokenType.
+	defaultTokenType = "JWT"
+)
+
 type tokenHeader struct {
 	Alg string `json:"alg"`
 	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
 }
 
 type tokenPayload struct {
@@ -34,6 +49,29 @@ type tokenPayload struct {
 	Sub      string `json:"sub"`
 	Uri      string `json:"uri"`
 	Kid      string `json:"kid"`
+	Aud      string `json:"aud,omitempty"`
+	// Extra specifies the custom claims merged into the payload, see Options.ExtraClaims.
+	Extra map[string]any `json:"-"`
+}
+
+// reservedClaims are the claims set by the client and the registered claims of RFC 7519, which
+// Options.ExtraClaims must not override.
+var reservedClaims = []string{"bodyHash", "exp", "iat", "nonce", "sub", "uri", "kid", "aud", "iss", "nbf", "jti"}
+
+// MarshalJSON encodes the claims of p followed by its extra claims sorted by name, so that the encoding
+// of the same payload is always the same.
+func (p tokenPayload) MarshalJSON() ([]byte, error) {
+	type claims tokenPayload
+	b, err := json.Marshal(claims(p))
+	if err != nil || len(p.Extra) == 0 {
+		return b, err
+	}
+	// encoding/json sorts the keys of maps
+	extra, err := json.Marshal(p.Extra)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(b[:len(b)-1], ','), extra[1:]...), nil
 }
 
 type token struct {
@@ -43,8 +81,14 @@ type token struct {
 }
 
 func newToken(keyID string, uri string, body []byte, ttl time.Duration, shouldCleanKey bool) (*token, error) {
-	nonceBuffer := make([]byte, 20)
-	if _, err := rand.Read(nonceBuffer); err != nil {
+	return newTokenFromReader(rand.Reader, defaultNonceBytes, keyID, uri, body, ttl, shouldCleanKey)
+}
+
+// newTokenFromReader is like newToken but reads the nonce of nonceBytes bytes from random, allowing tests to
+// produce a deterministic nonce.
+func newTokenFromReader(random io.Reader, nonceBytes int, keyID string, uri string, body []byte, ttl time.Duration, shouldCleanKey bool) (*token, error) {
+	nonceBuffer := make([]byte, nonceBytes)
+	if _, err := io.ReadFull(random, nonceBuffer); err != nil {
 		return nil, fmt.Errorf("wallet: newToken: failed to read random bytes. err=%v", err)
 	}
 
@@ -55,7 +99,7 @@ func newToken(keyID string, uri string, body []byte, ttl time.Duration, shouldCl
 		Header: &tokenHeader{
 			// alg is set when parsing the private key upon signing
 			Alg: "",
-			Typ: "JWT",
+			Typ: defaultTokenType,
 		},
 		Payload: &tokenPayload{
 			Kid:      keyID,
@@ -69,6 +113,18 @@ func newToken(keyID string, uri string, body []byte, ttl time.Duration, shouldCl
 	}, nil
 }
 
+// SignRequest returns a JWT authenticating a request with body to the API at uri, either "/query" or "/command",
+// signed with the EC or RSA private key privateKeyPEM identified by keyID and valid for ttl. It produces the
+// same tokens as the client, for instance, to embed them in messages sent without the client. The Authorization
+// header of the request is then "Bearer <token>".
+func SignRequest(keyID string, privateKeyPEM []byte, uri string, body []byte, ttl time.Duration) (string, error) {
+	token, err := newToken(keyID, uri, body, ttl, false)
+	if err != nil {
+		return "", err
+	}
+	return token.signAndFormat(privateKeyPEM)
+}
+
 func (t *token) signAndFormat(privateKeyPEM []byte) (string, error) {
 	// clean up the private key from memory
 	defer func() {
@@ -81,9 +137,40 @@ func (t *token) signAndFormat(privateKeyPEM []byte) (string, error) {
 		}
 	}()
 
+	key, alg, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return "", err
+	}
+	var signature string
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		signature, err = t.signWith(key, alg)
+		if err != nil {
+			return "", fmt.Errorf("wallet: signAndFormat: failed to sign with EC key. err=%v", err)
+		}
+		key.D = big.NewInt(0)
+		key.X = big.NewInt(0)
+		key.Y = big.NewInt(0)
+	case *rsa.PrivateKey:
+		signature, err = t.signWith(key, alg)
+		if err != nil {
+			return "", fmt.Errorf("wallet: signAndFormat: failed to sign with RSA key. err=%v", err)
+		}
+		key.D = big.NewInt(0)
+		key.N = big.NewInt(0)
+	}
+	key = nil
+
+	return signature, nil
+}
+
+// parsePrivateKey returns the EC or RSA private key of privateKeyPEM and the JWT alg matching it. The
+// returned key is safe for concurrent use, as [ecdsa.PrivateKey] and [rsa.PrivateKey] are, letting a key
+// parsed once sign many tokens concurrently, see [Options.CacheParsedKeys].
+func parsePrivateKey(privateKeyPEM []byte) (crypto.Signer, string, error) {
 	privateKeyBlock, _ := pem.Decode(privateKeyPEM)
 	if privateKeyBlock == nil {
-		return "", fmt.Errorf("wallet: signAndFormat: private key must be in PEM format.")
+		return nil, "", fmt.Errorf("wallet: signAndFormat: private key must be in PEM format.")
 	}
 	defer func() {
 		for i := range privateKeyBlock.Bytes {
@@ -101,58 +188,65 @@ func (t *token) signAndFormat(privateKeyPEM []byte) (string, error) {
 		if err != nil {
 			privateKeyAny, err = x509.ParsePKCS8PrivateKey(privateKeyBlock.Bytes)
 			if err != nil {
-				return "", fmt.Errorf("wallet: signAndFormat: unable to deduce private key type. Valid key would either be EC or RSA.")
+				return nil, "", fmt.Errorf("wallet: signAndFormat: unable to deduce private key type. Valid key would either be EC or RSA.")
 			}
 		}
 	}
 
-	var jsonBuffer bytes.Buffer
-	if err := json.NewEncoder(&jsonBuffer).Encode(t.Payload); err != nil {
-		return "", fmt.Errorf("wallet: signAndFormat: %v", err)
-	}
-	encodedPayload := base64.RawURLEncoding.EncodeToString(jsonBuffer.Bytes())
-	jsonBuffer.Reset()
-
-	signingString := ""
-	signatureB := []byte{}
 	switch key := privateKeyAny.(type) {
 	case *ecdsa.PrivateKey:
-		t.Header.Alg = es256
-		if err := json.NewEncoder(&jsonBuffer).Encode(t.Header); err != nil {
-			return "", fmt.Errorf("wallet: signAndFormat: %v", err)
-		}
-		encodedHeader := base64.RawURLEncoding.EncodeToString(jsonBuffer.Bytes())
-		signingString = encodedHeader + "." + encodedPayload
-		hashed := sha256.Sum256([]byte(signingString))
-		jsonBuffer.Reset()
-		signatureB, err = ecdsa.SignASN1(rand.Reader, key, hashed[:])
-		if err != nil {
-			return "", fmt.Errorf("wallet: signAndFormat: failed to sign with EC key. err=%v", err)
+		// the alg must match the curve of the key, ES256 requires P-256 for instance
+		switch key.Curve {
+		case elliptic.P256():
+			return key, es256, nil
+		case elliptic.P384():
+			return key, es384, nil
+		case elliptic.P521():
+			return key, es512, nil
+		default:
+			return nil, "", fmt.Errorf("wallet: signAndFormat: unsupported EC curve %s. Valid curve would either be P-256, P-384 or P-521.", key.Curve.Params().Name)
 		}
-		key.D = big.NewInt(0)
-		key.X = big.NewInt(0)
-		key.Y = big.NewInt(0)
-		key = nil
 	case *rsa.PrivateKey:
-		t.Header.Alg = rs256
-		if err := json.NewEncoder(&jsonBuffer).Encode(t.Header); err != nil {
-			return "", fmt.Errorf("wallet: signAndFormat: %v", err)
-		}
-		encodedHeader := base64.RawURLEncoding.EncodeToString(jsonBuffer.Bytes())
-		jsonBuffer.Reset()
-		signingString = encodedHeader + "." + encodedPayload
-		hashed := sha256.Sum256([]byte(signingString))
-		signatureB, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
-		if err != nil {
-			return "", fmt.Errorf("wallet: signAndFormat: failed to sign with RSA key. err=%v", err)
-		}
-		key.D = big.NewInt(0)
-		key.N = big.NewInt(0)
-		key = nil
+		return key, rs256, nil
+	default:
+		return nil, "", fmt.Errorf("wallet: signAndFormat: unable to cast private key type. Valid key would either be *[rsa.PrivateKey] or *[ecdsa.PrivateKey].")
+	}
+}
+
+// signWith returns the token signed by signer with alg, one of "ES256", "ES384", "ES512" or "RS256". ECDSA
+// signatures are ASN.1 encoded and RSA ones use PKCS #1 v1.5, as returned by the Sign method of
+// [ecdsa.PrivateKey] and [rsa.PrivateKey].
+func (t *token) signWith(signer crypto.Signer, alg string) (string, error) {
+	var hash crypto.Hash
+	switch alg {
+	case es256, rs256:
+		hash = crypto.SHA256
+	case es384:
+		hash = crypto.SHA384
+	case es512:
+		hash = crypto.SHA512
 	default:
-		return "", fmt.Errorf("wallet: signAndFormat: unable to cast private key type. Valid key would either be *[rsa.PrivateKey] or *[ecdsa.PrivateKey].")
+		return "", fmt.Errorf("wallet: signWith: unsupported alg %q. Valid alg would either be ES256, ES384, ES512 or RS256.", alg)
+	}
+	t.Header.Alg = alg
+
+	var jsonBuffer bytes.Buffer
+	if err := json.NewEncoder(&jsonBuffer).Encode(t.Header); err != nil {
+		return "", fmt.Errorf("wallet: signWith: %v", err)
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(jsonBuffer.Bytes())
+	jsonBuffer.Reset()
+	if err := json.NewEncoder(&jsonBuffer).Encode(t.Payload); err != nil {
+		return "", fmt.Errorf("wallet: signWith: %v", err)
 	}
-	privateKeyAny = nil
+	encodedPayload := base64.RawURLEncoding.EncodeToString(jsonBuffer.Bytes())
 
-	return signingString + "." + base64.RawURLEncoding.EncodeToString(signatureB), nil
+	signingString := encodedHeader + "." + encodedPayload
+	hasher := hash.New()
+	hasher.Write([]byte(signingString))
+	signature, err := signer.Sign(rand.Reader, hasher.Sum(nil), hash)
+	if err != nil {
+		return "", err
+	}
+	return signingString + "." + base64.RawURLEncoding.EncodeToString(signature), nil
 }
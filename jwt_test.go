@@ -0,0 +1,375 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenSubjectAndAudience(t *testing.T) {
+	var payload map[string]any
+	rt := func(req *http.Request) (*http.Response, error) {
+		_, payload = decodeTestToken(t, req)
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	}
+
+	c := newTestClient(t, nil, rt)
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := payload["aud"]; ok || payload["sub"] != "wallet" {
+		t.Errorf("got payload %v, want default sub and no aud", payload)
+	}
+
+	c = newTestClient(t, &Options{Subject: "reconciliation-service", Audience: "https://gateway.example.com"}, rt)
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if payload["sub"] != "reconciliation-service" || payload["aud"] != "https://gateway.example.com" {
+		t.Errorf("got payload %v", payload)
+	}
+}
+
+func TestTokenDeterministicNonce(t *testing.T) {
+	var nonces []any
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		_, payload := decodeTestToken(t, req)
+		nonces = append(nonces, payload["nonce"])
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+
+	for i := 0; i < 2; i++ {
+		c.randReader = bytes.NewReader(bytes.Repeat([]byte{0xab}, 20))
+		if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := strings.Repeat("ab", 20)
+	if len(nonces) != 2 || nonces[0] != want || nonces[1] != want {
+		t.Errorf("got nonces %v, want %s twice", nonces, want)
+	}
+}
+
+func TestTokenNonceBytes(t *testing.T) {
+	for _, tt := range []struct {
+		nonceBytes int
+		wantLen    int
+	}{
+		{0, 40},
+		{16, 32},
+		{32, 64},
+	} {
+		var nonce string
+		c := newTestClient(t, &Options{NonceBytes: tt.nonceBytes}, func(req *http.Request) (*http.Response, error) {
+			_, payload := decodeTestToken(t, req)
+			nonce, _ = payload["nonce"].(string)
+			return jsonResponse(http.StatusOK, map[string]any{}), nil
+		})
+		if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+			t.Fatal(err)
+		}
+		if len(nonce) != tt.wantLen {
+			t.Errorf("NonceBytes %d: got nonce %q of length %d, want %d", tt.nonceBytes, nonce, len(nonce), tt.wantLen)
+		}
+	}
+}
+
+func TestTokenNonceBytesTooShort(t *testing.T) {
+	var werr Error
+	if _, err := NewWithOptions(&Options{NonceBytes: 8}); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got error %v for NonceBytes below 16, want %s", err, ErrInvalidParameter)
+	}
+}
+
+func TestTokenType(t *testing.T) {
+	var header map[string]any
+	rt := func(req *http.Request) (*http.Response, error) {
+		header, _ = decodeTestToken(t, req)
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	}
+
+	c := newTestClient(t, nil, rt)
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if header["typ"] != "JWT" {
+		t.Errorf("got typ %v, want JWT", header["typ"])
+	}
+
+	c = newTestClient(t, &Options{TokenType: "at+jwt"}, rt)
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if header["typ"] != "at+jwt" {
+		t.Errorf("got typ %v, want at+jwt", header["typ"])
+	}
+}
+
+func TestTokenTypeBlank(t *testing.T) {
+	var werr Error
+	if _, err := NewWithOptions(&Options{TokenType: " "}); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got error %v for a blank TokenType, want %s", err, ErrInvalidParameter)
+	}
+}
+
+func TestTokenExtraClaims(t *testing.T) {
+	var tokens []string
+	var payload map[string]any
+	c := newTestClient(t, &Options{ExtraClaims: map[string]any{"tenant": "acme", "region": "my"}}, func(req *http.Request) (*http.Response, error) {
+		_, payload = decodeTestToken(t, req)
+		tokens = append(tokens, strings.Split(req.Header.Get("Authorization"), ".")[1])
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if payload["tenant"] != "acme" || payload["region"] != "my" || payload["sub"] != "wallet" || payload["bodyHash"] == "" {
+		t.Errorf("got payload %v, want the tenant and region claims along with the claims of the client", payload)
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(tokens[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(bytes.TrimSpace(claims), []byte(`,"region":"my","tenant":"acme"}`)) {
+		t.Errorf("got claims %s, want the extra claims sorted last", claims)
+	}
+
+	for _, name := range []string{"sub", "bodyHash", "jti"} {
+		var werr Error
+		if _, err := NewWithOptions(&Options{ExtraClaims: map[string]any{name: "x"}}); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+			t.Errorf("got error %v for the reserved claim %q, want %s", err, name, ErrInvalidParameter)
+		}
+	}
+}
+
+func TestTokenKidInHeader(t *testing.T) {
+	var header, payload map[string]any
+	rt := func(req *http.Request) (*http.Response, error) {
+		header, payload = decodeTestToken(t, req)
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	}
+
+	c := newTestClient(t, nil, rt)
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := header["kid"]; ok {
+		t.Errorf("got header %v, want no kid by default", header)
+	}
+
+	c = newTestClient(t, &Options{IncludeKidInHeader: true}, rt)
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if header["kid"] != testKeyID || payload["kid"] != testKeyID {
+		t.Errorf("got header %v and payload %v, want kid %s in both", header, payload, testKeyID)
+	}
+}
+
+func TestSignRequestVerifies(t *testing.T) {
+	privateKeyPEM := testECPrivateKeyPEM(t)
+	body := []byte(`{"name":"list_banks","payload":{}}`)
+	token, err := SignRequest(testKeyID, privateKeyPEM, "/query", body, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(privateKeyPEM)
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token %q", token)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature) {
+		t.Error("the signature does not verify with the public key")
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/query", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	header, payload := decodeTestToken(t, req)
+	if header["alg"] != "ES256" || payload["kid"] != testKeyID || payload["uri"] != "/query" {
+		t.Errorf("got header %v and payload %v", header, payload)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256(body)); payload["bodyHash"] != want {
+		t.Errorf("got bodyHash %v, want %s", payload["bodyHash"], want)
+	}
+}
+
+func TestSignRequestECCurves(t *testing.T) {
+	for _, tt := range []struct {
+		curve elliptic.Curve
+		alg   string
+		hash  crypto.Hash
+	}{
+		{elliptic.P256(), "ES256", crypto.SHA256},
+		{elliptic.P384(), "ES384", crypto.SHA384},
+		{elliptic.P521(), "ES512", crypto.SHA512},
+	} {
+		key, err := ecdsa.GenerateKey(tt.curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := SignRequest(testKeyID, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), "/query", []byte("{}"), time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := strings.Split(token, ".")
+		header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+		if want := fmt.Sprintf(`"alg":%q`, tt.alg); !strings.Contains(string(header), want) {
+			t.Errorf("%s: got header %s, want %s", tt.curve.Params().Name, header, want)
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		hasher := tt.hash.New()
+		hasher.Write([]byte(parts[0] + "." + parts[1]))
+		if !ecdsa.VerifyASN1(&key.PublicKey, hasher.Sum(nil), signature) {
+			t.Errorf("%s: the signature does not verify with the public key", tt.curve.Params().Name)
+		}
+	}
+}
+
+// fakeSigner is a [crypto.Signer] hiding its key, as the ones backed by an HSM or a KMS.
+type fakeSigner struct {
+	key   *ecdsa.PrivateKey
+	calls int
+}
+
+func (s *fakeSigner) Public() crypto.PublicKey {
+	return &s.key.PublicKey
+}
+
+func (s *fakeSigner) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.calls++
+	return ecdsa.SignASN1(random, s.key, digest)
+}
+
+func TestSignerFunc(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &fakeSigner{key: key}
+	var authorization string
+	c := New(&Options{
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			authorization = strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+			return jsonResponse(http.StatusOK, map[string]any{}), nil
+		})},
+		SignerFunc: func(ctx context.Context) (string, crypto.Signer, string, error) {
+			return testKeyID, signer, "ES384", nil
+		},
+	})
+
+	if err := c.ValidateCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(authorization, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token %q", authorization)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher := crypto.SHA384.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	if !ecdsa.VerifyASN1(&key.PublicKey, hasher.Sum(nil), signature) {
+		t.Error("the signature does not verify with the public key")
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(header), `"alg":"ES384"`) {
+		t.Errorf("got header %s, want ES384", header)
+	}
+	if signer.calls != 2 {
+		t.Errorf("got %d signatures, want 2", signer.calls)
+	}
+
+	// an unsupported alg is rejected without sending the request.
+	c = New(&Options{SignerFunc: func(ctx context.Context) (string, crypto.Signer, string, error) {
+		return testKeyID, signer, "HS256", nil
+	}})
+	if err := c.ValidateCredentials(); err == nil {
+		t.Error("got no error for alg HS256")
+	}
+}
+
+func TestCacheParsedKeysConcurrentSigning(t *testing.T) {
+	privateKeyPEM := testECPrivateKeyPEM(t)
+	block, _ := pem.Decode(privateKeyPEM)
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(&Options{
+		CacheParsedKeys: true,
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			parts := strings.Split(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), ".")
+			if len(parts) != 3 {
+				t.Errorf("malformed token %q", parts)
+				return jsonResponse(http.StatusUnauthorized, map[string]any{"code": ErrInvalidAuthSignature}), nil
+			}
+			signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+			if err != nil {
+				t.Error(err)
+			}
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature) {
+				t.Error("the signature does not verify with the public key")
+			}
+			return jsonResponse(http.StatusOK, map[string]any{"banks": []map[string]any{}}), nil
+		})},
+	})
+	c.SetCredentials(testKeyID, privateKeyPEM)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the key is parsed once, and is left intact by signing.
+	if c.credentials[0].signer == nil || c.credentials[0].alg != "ES256" {
+		t.Errorf("got signer %v and alg %q, want the parsed key cached", c.credentials[0].signer, c.credentials[0].alg)
+	}
+	if !c.credentials[0].signer.(*ecdsa.PrivateKey).Equal(key) {
+		t.Error("the cached key was altered by signing")
+	}
+}
@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"os"
+)
+
+// StreamEncoder is implemented by the codecs able to encode v to w without holding the encoding in memory,
+// letting the client stream large command bodies, see [Options.LargeCommandBodyThreshold]. The encoding must be
+// the same as the one of Marshal, up to a trailing newline, and be deterministic. The default codec implements it.
+type StreamEncoder interface {
+	Encode(w io.Writer, v any) error
+}
+
+// largeBody is a command body too large to be held in memory, see Options.LargeCommandBodyThreshold.
+type largeBody struct {
+	// hash is the SHA-256 hash of the body, signed by the bodyHash claim of the token.
+	hash []byte
+	size int64
+	// open returns the body from its start, for each attempt.
+	open func() (io.ReadCloser, error)
+	// close releases the resources of the body once the call is done.
+	close func() error
+}
+
+// countingWriter counts the bytes written to it, and written to w when set.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if w.w == nil {
+		w.n += int64(len(p))
+		return len(p), nil
+	}
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// encodeLargeBody returns v encoded as a largeBody when its encoding exceeds LargeCommandBodyThreshold, otherwise
+// nil, in which case v is to be encoded with encodeBody.
+func (c *Client) encodeLargeBody(v any) (*largeBody, error) {
+	o := c.options
+	encoder, ok := o.Codec.(StreamEncoder)
+	if o.LargeCommandBodyThreshold <= 0 || !ok {
+		return nil, nil
+	}
+	// the first pass hashes and measures the body without holding it
+	hash := sha256.New()
+	var size countingWriter
+	if err := encoder.Encode(io.MultiWriter(hash, &size), v); err != nil {
+		return nil, err
+	}
+	if size.n <= o.LargeCommandBodyThreshold {
+		return nil, nil
+	}
+	body := &largeBody{hash: hash.Sum(nil), size: size.n}
+
+	if o.LargeCommandBodyDir == "" {
+		// the body is encoded again for each attempt while being sent
+		body.open = func() (io.ReadCloser, error) {
+			r, w := io.Pipe()
+			go func() {
+				w.CloseWithError(encoder.Encode(w, v))
+			}()
+			return r, nil
+		}
+		body.close = func() error { return nil }
+		return body, nil
+	}
+
+	// the body is spooled to a file, sent as is by every attempt
+	f, err := os.CreateTemp(o.LargeCommandBodyDir, "wallet-command-*.json")
+	if err != nil {
+		return nil, err
+	}
+	body.close = func() error {
+		f.Close()
+		return os.Remove(f.Name())
+	}
+	hash.Reset()
+	size.n = 0
+	if err := encoder.Encode(io.MultiWriter(f, hash, &size), v); err != nil {
+		body.close()
+		return nil, err
+	}
+	body.hash = hash.Sum(nil)
+	body.size = size.n
+	body.open = func() (io.ReadCloser, error) {
+		return io.NopCloser(io.NewSectionReader(f, 0, body.size)), nil
+	}
+	return body, nil
+}
+
+// attach sets the body of req to b.
+func (b *largeBody) attach(req *http.Request) error {
+	body, err := b.open()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	req.ContentLength = b.size
+	req.GetBody = b.open
+	return nil
+}
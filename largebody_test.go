@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestLargeCommandBody(t *testing.T) {
+	plan := make([]CreateSwitchRequestInput, 5000)
+	for i := range plan {
+		plan[i] = CreateSwitchRequestInput{
+			AccountID:                   "acc_1",
+			SwitchFromFundID:            fmt.Sprintf("fund_%d", i),
+			SwitchFromFundClassSequence: 1,
+			SwitchToFundID:              fmt.Sprintf("fund_%d", i+len(plan)),
+			SwitchToFundClassSequence:   1,
+			RequestedAmount:             1000,
+		}
+	}
+	for _, dir := range []string{"", t.TempDir()} {
+		var hashes []string
+		c := newTestClient(t, &Options{LargeCommandBodyThreshold: 64 << 10, LargeCommandBodyDir: dir}, func(req *http.Request) (*http.Response, error) {
+			_, payload := decodeTestToken(t, req)
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.ContentLength != int64(len(body)) || len(body) <= 64<<10 {
+				t.Errorf("dir %q: got %d bytes and Content-Length %d, want a large body", dir, len(body), req.ContentLength)
+			}
+			hash := fmt.Sprintf("%x", sha256.Sum256(body))
+			if payload["bodyHash"] != hash {
+				t.Errorf("dir %q: got bodyHash %v, want %s", dir, payload["bodyHash"], hash)
+			}
+			hashes = append(hashes, hash)
+			var sent struct {
+				Name    string                     `json:"name"`
+				Payload []CreateSwitchRequestInput `json:"payload"`
+			}
+			if err := json.Unmarshal(body, &sent); err != nil {
+				t.Fatal(err)
+			}
+			if sent.Name != "create_switch_plan" || len(sent.Payload) != len(plan) || sent.Payload[4999] != plan[4999] {
+				t.Errorf("dir %q: got %s of %d entries", dir, sent.Name, len(sent.Payload))
+			}
+			// the first attempt fails so the body is sent again.
+			if len(hashes) == 1 {
+				return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+			}
+			return jsonResponse(http.StatusOK, map[string]any{}), nil
+		})
+		var output map[string]any
+		if err := c.command(context.Background(), "create_switch_plan", plan, &output); err != nil {
+			t.Fatalf("dir %q: %v", dir, err)
+		}
+		if len(hashes) != 2 || hashes[0] != hashes[1] {
+			t.Errorf("dir %q: got hashes %v, want two identical attempts", dir, hashes)
+		}
+		if dir != "" {
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("got %d files left in %s, want the spooled body removed", len(entries), dir)
+			}
+		}
+	}
+}
+
+func TestLargeCommandBodyThreshold(t *testing.T) {
+	var contentLength int64
+	c := newTestClient(t, &Options{LargeCommandBodyThreshold: 64 << 10}, func(req *http.Request) (*http.Response, error) {
+		contentLength = req.ContentLength
+		return jsonResponse(http.StatusOK, map[string]any{"requestId": "req_1"}), nil
+	})
+	input := &CreateInvestmentRequestInput{AccountID: "acc_1", FundID: "fund_1", FundClassSequence: 1, Amount: NewAmount(1000, "MYR")}
+	if _, err := c.CreateInvestmentRequest(context.Background(), input); err != nil {
+		t.Fatal(err)
+	}
+	// small bodies are encoded with Marshal, without a trailing newline.
+	body, _ := json.Marshal(commandInput{Name: OperationCreateInvestmentRequest, Payload: input})
+	if contentLength != int64(len(body)) {
+		t.Errorf("got Content-Length %d, want %d", contentLength, len(body))
+	}
+}
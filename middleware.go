@@ -0,0 +1,18 @@
+package wallet
+
+import "net/http"
+
+// applyMiddlewares returns a copy of client whose transport is wrapped by middlewares,
+// the first middleware being the outermost.
+func applyMiddlewares(client *http.Client, middlewares []func(http.RoundTripper) http.RoundTripper) *http.Client {
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+	wrapped := *client
+	wrapped.Transport = transport
+	return &wrapped
+}
@@ -0,0 +1,51 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewares(t *testing.T) {
+	var calls []string
+	middleware := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+					t.Errorf("%s: request is not signed", name)
+				}
+				calls = append(calls, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	o := &Options{
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls = append(calls, "transport")
+			return jsonResponse(http.StatusOK, map[string]any{}), nil
+		})},
+		Middlewares: []func(http.RoundTripper) http.RoundTripper{middleware("first"), middleware("second")},
+	}
+	c := New(o)
+	c.SetCredentials(testKeyID, testECPrivateKeyPEM(t))
+
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(calls), "[first second transport]"; got != want {
+		t.Errorf("got calls %s, want %s", got, want)
+	}
+
+	// another client built from the same options wraps the middlewares once.
+	calls = nil
+	c = New(o)
+	c.SetCredentials(testKeyID, testECPrivateKeyPEM(t))
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(calls), "[first second transport]"; got != want {
+		t.Errorf("second client: got calls %s, want %s", got, want)
+	}
+}
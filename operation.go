@@ -0,0 +1,50 @@
+package wallet
+
+// Operation is the name of a query or a command, as sent on the wire.
+type Operation string
+
+// Operations of the query APIs.
+const (
+	OperationGetClientAccountAllocationPerformance Operation = "get_client_account_allocation_performance"
+	OperationGetClientAccountRequestConfirmation   Operation = "get_client_account_request_confirmation"
+	OperationGetClientAccountRequestPolicy         Operation = "get_client_account_request_policy"
+	OperationGetClientAccountStatement             Operation = "get_client_account_statement"
+	OperationGetClientProfile                      Operation = "get_client_profile"
+	OperationGetClientReferral                     Operation = "get_client_referral"
+	OperationGetFund                               Operation = "get_fund"
+	OperationGetGoalProjection                     Operation = "get_goal_projection"
+	OperationGetJointInvitationStatus              Operation = "get_joint_invitation_status"
+	OperationGetPreviewInvest                      Operation = "get_preview_invest"
+	OperationGetProjectedFundPrice                 Operation = "get_projected_fund_price"
+	OperationGetRequestByDuitNowEndToEndID         Operation = "get_request_by_duitnow_end_to_end_id"
+	OperationGetVoucher                            Operation = "get_voucher"
+	OperationListBanks                             Operation = "list_banks"
+	OperationListClientAccountBalance              Operation = "list_client_account_balance"
+	OperationListClientAccountMandateRequests      Operation = "list_client_account_mandate_requests"
+	OperationListClientAccountPerformance          Operation = "list_client_account_performance"
+	OperationListClientAccountRequests             Operation = "list_client_account_requests"
+	OperationListClientAccounts                    Operation = "list_client_accounts"
+	OperationListClientBankAccounts                Operation = "list_client_bank_accounts"
+	OperationListClientPromos                      Operation = "list_client_promos"
+	OperationListClientSuitabilityAssessments      Operation = "list_client_suitability_assessments"
+	OperationListDisplayCurrencies                 Operation = "list_display_currencies"
+	OperationListDuitNowBanks                      Operation = "list_duitnow_banks"
+	OperationListFundsForSubscription              Operation = "list_funds_for_subscription"
+	OperationListInvestConsents                    Operation = "list_invest_consents"
+	OperationListPaymentMethods                    Operation = "list_payment_methods"
+)
+
+// Operations of the command APIs.
+const (
+	OperationCreateClientBankAccount     Operation = "create_client_bank_account"
+	OperationCreateDuitnowPayment        Operation = "create_duitnow_payment"
+	OperationCreateInvestmentRequest     Operation = "create_investment_request"
+	OperationCreateRedemptionRequest     Operation = "create_redemption_request"
+	OperationCreateRequestCancellation   Operation = "create_request_cancellation"
+	OperationCreateSuitabilityAssessment Operation = "create_suitability_assessment"
+	OperationCreateSwitchRequest         Operation = "create_switch_request"
+	OperationInviteCoHolder              Operation = "invite_co_holder"
+	OperationUpdateAccountName           Operation = "update_account_name"
+	OperationUpdateClientProfile         Operation = "update_client_profile"
+	OperationUpdateDisplayCurrency       Operation = "update_display_currency"
+)
@@ -0,0 +1,53 @@
+package wallet
+
+import "testing"
+
+func TestOperations(t *testing.T) {
+	for _, tt := range []struct {
+		operation Operation
+		want      string
+	}{
+		{OperationGetClientAccountAllocationPerformance, "get_client_account_allocation_performance"},
+		{OperationGetClientAccountRequestConfirmation, "get_client_account_request_confirmation"},
+		{OperationGetClientAccountRequestPolicy, "get_client_account_request_policy"},
+		{OperationGetClientAccountStatement, "get_client_account_statement"},
+		{OperationGetClientProfile, "get_client_profile"},
+		{OperationGetClientReferral, "get_client_referral"},
+		{OperationGetFund, "get_fund"},
+		{OperationGetGoalProjection, "get_goal_projection"},
+		{OperationGetJointInvitationStatus, "get_joint_invitation_status"},
+		{OperationGetPreviewInvest, "get_preview_invest"},
+		{OperationGetProjectedFundPrice, "get_projected_fund_price"},
+		{OperationGetRequestByDuitNowEndToEndID, "get_request_by_duitnow_end_to_end_id"},
+		{OperationGetVoucher, "get_voucher"},
+		{OperationListBanks, "list_banks"},
+		{OperationListClientAccountBalance, "list_client_account_balance"},
+		{OperationListClientAccountMandateRequests, "list_client_account_mandate_requests"},
+		{OperationListClientAccountPerformance, "list_client_account_performance"},
+		{OperationListClientAccountRequests, "list_client_account_requests"},
+		{OperationListClientAccounts, "list_client_accounts"},
+		{OperationListClientBankAccounts, "list_client_bank_accounts"},
+		{OperationListClientPromos, "list_client_promos"},
+		{OperationListClientSuitabilityAssessments, "list_client_suitability_assessments"},
+		{OperationListDisplayCurrencies, "list_display_currencies"},
+		{OperationListDuitNowBanks, "list_duitnow_banks"},
+		{OperationListFundsForSubscription, "list_funds_for_subscription"},
+		{OperationListInvestConsents, "list_invest_consents"},
+		{OperationListPaymentMethods, "list_payment_methods"},
+		{OperationCreateClientBankAccount, "create_client_bank_account"},
+		{OperationCreateDuitnowPayment, "create_duitnow_payment"},
+		{OperationCreateInvestmentRequest, "create_investment_request"},
+		{OperationCreateRedemptionRequest, "create_redemption_request"},
+		{OperationCreateRequestCancellation, "create_request_cancellation"},
+		{OperationCreateSuitabilityAssessment, "create_suitability_assessment"},
+		{OperationCreateSwitchRequest, "create_switch_request"},
+		{OperationInviteCoHolder, "invite_co_holder"},
+		{OperationUpdateAccountName, "update_account_name"},
+		{OperationUpdateClientProfile, "update_client_profile"},
+		{OperationUpdateDisplayCurrency, "update_display_currency"},
+	} {
+		if string(tt.operation) != tt.want {
+			t.Errorf("got %q, want %q", tt.operation, tt.want)
+		}
+	}
+}
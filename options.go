@@ -0,0 +1,124 @@
+package wallet
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CallOption configures a single call, overriding the client's [Options] for that call only.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	// disableRetry reports whether the call is attempted once regardless of the error.
+	disableRetry bool
+	// header is added to the request headers.
+	header http.Header
+	// trace is called with the timings of each attempt, see WithTrace.
+	trace func(Trace)
+	// responseMeta is set from the response, see WithResponseMeta.
+	responseMeta *ResponseMeta
+	// timeout overrides the timeout of the operation, see WithTimeout.
+	timeout time.Duration
+	// maxResponseBytes overrides Options.MaxResponseBytes when not zero, see WithMaxResponseBytes.
+	maxResponseBytes int64
+	// withoutAuth reports whether the request is sent unsigned, see WithoutAuth.
+	withoutAuth bool
+}
+
+func newCallOptions(opts []CallOption) *callOptions {
+	o := &callOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithoutRetry makes the call fail fast by attempting it exactly once, overriding [Options.MaxReadRetry]
+// and the automatic retry of rate-limited requests. It suits latency-sensitive reads that would rather
+// fallback to a cached value than wait for retries.
+func WithoutRetry() CallOption {
+	return func(o *callOptions) {
+		o.disableRetry = true
+	}
+}
+
+// WithTimeout sets the timeout of the call, including its retries, overriding [Options.OperationTimeouts].
+// An earlier deadline of the context of the call still applies.
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithMaxResponseBytes sets the maximum size of the response body of the call, overriding [Options.MaxResponseBytes],
+// for instance, to download a large statement without raising the limit of every call. A negative n lifts the
+// limit for the call.
+func WithMaxResponseBytes(n int64) CallOption {
+	return func(o *callOptions) {
+		o.maxResponseBytes = n
+	}
+}
+
+// unauthenticatedOperations are the operations public reference data is retrieved with, which may be
+// called WithoutAuth.
+var unauthenticatedOperations = []Operation{OperationListBanks, OperationListDuitNowBanks, OperationListDisplayCurrencies}
+
+// WithoutAuth sends the call unsigned, without requiring credentials, for instance, to list the banks on a
+// screen shown before the user logs in. It only applies to the public reference data, that is, [Client.ListBanks],
+// [Client.ListDuitNowBanks] and [Client.ListDisplayCurrencies], other calls fail with [ErrInvalidParameter].
+func WithoutAuth() CallOption {
+	return func(o *callOptions) {
+		o.withoutAuth = true
+	}
+}
+
+// withHeader adds header to the request headers.
+func withHeader(header http.Header) CallOption {
+	return func(o *callOptions) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		for key, values := range header {
+			o.header[key] = values
+		}
+	}
+}
+
+// ResponseMeta holds metadata of a response, see [WithResponseMeta].
+type ResponseMeta struct {
+	// StatusCode specifies the status code of the response.
+	StatusCode int
+	// Header specifies the headers of the response. The values of the headers carrying credentials are redacted,
+	// see [Options.UnmaskSensitiveHeaders].
+	Header http.Header
+	// RequestID specifies the identifier of the request given by the server in the X-Request-Id header,
+	// to quote when contacting support.
+	RequestID string
+	// RateLimitRemaining specifies the number of requests remaining in the current rate limit window
+	// given by the server in the X-RateLimit-Remaining header, -1 when the header is missing.
+	RateLimitRemaining int
+}
+
+// WithResponseMeta sets *meta from the response of the call, including error responses, once the call
+// returns. When the call is retried, meta describes the last response. It is left untouched when no
+// response is received, for instance, when the response is served from the cache.
+func WithResponseMeta(meta *ResponseMeta) CallOption {
+	return func(o *callOptions) {
+		o.responseMeta = meta
+	}
+}
+
+// setResponseMeta sets *meta from resp, with header being the redacted headers of resp.
+func setResponseMeta(meta *ResponseMeta, resp *http.Response, header http.Header) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		remaining = -1
+	}
+	*meta = ResponseMeta{
+		StatusCode:         resp.StatusCode,
+		Header:             header,
+		RequestID:          resp.Header.Get("X-Request-Id"),
+		RateLimitRemaining: remaining,
+	}
+}
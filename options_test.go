@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithoutRetry(t *testing.T) {
+	attempts := 0
+	c := newTestClient(t, &Options{MaxReadRetry: 5, RetryInterval: time.Millisecond}, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return jsonResponse(http.StatusInternalServerError, map[string]any{"code": ErrInternal}), nil
+	})
+
+	_, err := c.ListClientAccounts(context.Background(), &ListClientAccountsInput{}, WithoutRetry())
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInternal {
+		t.Fatalf("got %v, want %s", err, ErrInternal)
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+
+	// the client default still applies to other calls
+	attempts = 0
+	_, _ = c.ListClientAccounts(context.Background(), &ListClientAccountsInput{})
+	if attempts != 5 {
+		t.Errorf("got %d attempts, want 5", attempts)
+	}
+}
+
+func TestWithTrace(t *testing.T) {
+	c := newTestClient(t, &Options{MaxReadRetry: 2, RetryInterval: time.Millisecond}, func(req *http.Request) (*http.Response, error) {
+		time.Sleep(5 * time.Millisecond)
+		return jsonResponse(http.StatusInternalServerError, map[string]any{"code": ErrInternal}), nil
+	})
+
+	var traces []Trace
+	_, _ = c.ListClientAccounts(context.Background(), &ListClientAccountsInput{}, WithTrace(func(trace Trace) {
+		traces = append(traces, trace)
+	}))
+	// the mock transport does not dial, only the total duration is recorded.
+	if len(traces) != 2 {
+		t.Fatalf("got %d traces, want one per attempt", len(traces))
+	}
+	for _, trace := range traces {
+		if trace.Total < 5*time.Millisecond {
+			t.Errorf("got total %s, want at least 5ms", trace.Total)
+		}
+	}
+}
+
+func TestWithResponseMeta(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		resp := jsonResponse(http.StatusOK, map[string]any{})
+		resp.Header.Set("X-Request-Id", "req_abc")
+		resp.Header.Set("X-RateLimit-Remaining", "42")
+		resp.Header.Set("X-Total-Count", "120")
+		return resp, nil
+	})
+
+	var meta ResponseMeta
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}, WithResponseMeta(&meta)); err != nil {
+		t.Fatal(err)
+	}
+	if meta.StatusCode != http.StatusOK || meta.RequestID != "req_abc" || meta.RateLimitRemaining != 42 {
+		t.Errorf("got meta %+v", meta)
+	}
+	if got := meta.Header.Get("X-Total-Count"); got != "120" {
+		t.Errorf("got X-Total-Count %q, want 120", got)
+	}
+}
+
+func TestOperationTimeouts(t *testing.T) {
+	var remaining time.Duration
+	c := newTestClient(t, &Options{
+		OperationTimeouts: map[Operation]time.Duration{
+			OperationGetClientAccountStatement: 30 * time.Second,
+			OperationListBanks:                 5 * time.Second,
+		},
+	}, func(req *http.Request) (*http.Response, error) {
+		deadline, _ := req.Context().Deadline()
+		remaining = time.Until(deadline)
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+	// the timeout of the HTTP client must be longer for the operation timeouts to apply.
+	c.options.HTTPClient.Timeout = 2 * time.Minute
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name string
+		call func() error
+		want time.Duration
+	}{
+		{"statement", func() error {
+			_, err := c.GetClientAccountStatement(ctx, &GetClientAccountStatementInput{})
+			return err
+		}, 30 * time.Second},
+		{"streamed statement", func() error {
+			return c.StreamClientAccountStatement(ctx, &GetClientAccountStatementInput{}, func(tx StatementTransaction) error { return nil })
+		}, 30 * time.Second},
+		{"banks", func() error {
+			_, err := c.ListBanks(ctx, &ListBanksInput{})
+			return err
+		}, 5 * time.Second},
+		{"call option", func() error {
+			_, err := c.GetClientAccountStatement(ctx, &GetClientAccountStatementInput{}, WithTimeout(time.Minute))
+			return err
+		}, time.Minute},
+		{"earlier context deadline", func() error {
+			ctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			_, err := c.GetClientAccountStatement(ctx, &GetClientAccountStatementInput{})
+			return err
+		}, time.Second},
+	} {
+		if err := tt.call(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if remaining <= tt.want-time.Second/2 || remaining > tt.want {
+			t.Errorf("%s: got a deadline in %s, want %s", tt.name, remaining, tt.want)
+		}
+	}
+
+	// operations without a timeout are only bound by the timeout of the HTTP client.
+	if _, err := c.ListDisplayCurrencies(ctx, &ListDisplayCurrenciesInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := 2 * time.Minute; remaining <= want-time.Second/2 || remaining > want {
+		t.Errorf("got a deadline in %s, want %s", remaining, want)
+	}
+}
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	statement := bytes.Repeat([]byte("%PDF-1.7 "), 1024)
+	c := newTestClient(t, &Options{MaxResponseBytes: 2048}, func(req *http.Request) (*http.Response, error) {
+		switch decodeTestRequest(t, req).Name {
+		case "get_client_account_statement":
+			return jsonResponse(http.StatusOK, map[string]any{"format": "pdf", "bytes": statement}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{
+			"banks": []map[string]any{{"name": strings.Repeat("Maybank ", 512), "bic": "MBBEMYKL"}},
+		}), nil
+	})
+	ctx := context.Background()
+	input := &GetClientAccountStatementInput{AccountID: "acc_1", FromDate: "2024-01-01", ToDate: "2024-12-31"}
+
+	var werr Error
+	if _, err := c.GetClientAccountStatement(ctx, input); !errors.As(err, &werr) || werr.Code != ErrResponseTooLarge {
+		t.Errorf("got error %v, want %s", err, ErrResponseTooLarge)
+	}
+	output, err := c.GetClientAccountStatement(ctx, input, WithMaxResponseBytes(1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output.Bytes, statement) {
+		t.Errorf("got %d bytes, want the %d bytes of the statement", len(output.Bytes), len(statement))
+	}
+	// the other calls still enforce the limit of the client.
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}); !errors.As(err, &werr) || werr.Code != ErrResponseTooLarge {
+		t.Errorf("got error %v, want %s", err, ErrResponseTooLarge)
+	}
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}, WithMaxResponseBytes(-1)); err != nil {
+		t.Errorf("got error %v with the limit lifted", err)
+	}
+}
+
+func TestWithoutAuth(t *testing.T) {
+	var authorization []string
+	c := New(&Options{HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		authorization = append(authorization, req.Header.Get("Authorization"))
+		return jsonResponse(http.StatusOK, map[string]any{"banks": []map[string]any{{"name": "Maybank", "bic": "MBBEMYKL"}}}), nil
+	})}})
+	ctx := context.Background()
+
+	// no credentials are set.
+	output, err := c.ListBanks(ctx, &ListBanksInput{}, WithoutAuth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Banks) != 1 || len(authorization) != 1 || authorization[0] != "" {
+		t.Errorf("got banks %v and Authorization %q, want the banks sent unsigned", output.Banks, authorization)
+	}
+
+	var werr Error
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}); !errors.As(err, &werr) || werr.Code != ErrCredentialsNotSet {
+		t.Errorf("got error %v, want %s without WithoutAuth", err, ErrCredentialsNotSet)
+	}
+	if _, err := c.ListClientAccounts(ctx, &ListClientAccountsInput{}, WithoutAuth()); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got error %v, want %s for an operation requiring auth", err, ErrInvalidParameter)
+	}
+	if len(authorization) != 1 {
+		t.Errorf("sent %d requests, want 1", len(authorization))
+	}
+}
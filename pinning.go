@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// pinCertificates returns a copy of client whose transport rejects connections to servers whose leaf
+// certificate SHA-256 fingerprint is not one of fingerprints.
+//
+// The transport of client must be nil or an [*http.Transport] for the pins to be enforced, see Options.validate.
+func pinCertificates(client *http.Client, fingerprints []string) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t, ok := client.Transport.(*http.Transport); ok {
+		transport = t.Clone()
+	}
+
+	pins := make(map[string]struct{}, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		pins[normalizeFingerprint(fingerprint)] = struct{}{}
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	verify := transport.TLSClientConfig.VerifyPeerCertificate
+	transport.TLSClientConfig.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if verify != nil {
+			if err := verify(rawCerts, verifiedChains); err != nil {
+				return err
+			}
+		}
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("wallet: no server certificate to match the pinned fingerprints")
+		}
+		sum := sha256.Sum256(rawCerts[0])
+		fingerprint := hex.EncodeToString(sum[:])
+		if _, ok := pins[fingerprint]; !ok {
+			return fmt.Errorf("wallet: server certificate fingerprint %s is not pinned", fingerprint)
+		}
+		return nil
+	}
+
+	pinned := *client
+	pinned.Transport = transport
+	return &pinned
+}
+
+// normalizeFingerprint lowercases a hex fingerprint and removes its colon separators, if any.
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
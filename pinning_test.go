@@ -0,0 +1,88 @@
+package wallet
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newPinnedTestClient returns a client pinned to fingerprints whose requests are routed to srv.
+func newPinnedTestClient(t *testing.T, srv *httptest.Server, fingerprints []string) *Client {
+	t.Helper()
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+		},
+		// the test certificate is not issued for the API host, only the pins are checked.
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	c := New(&Options{
+		HTTPClient:             &http.Client{Transport: transport},
+		PinnedCertFingerprints: fingerprints,
+	})
+	c.SetCredentials(testKeyID, testECPrivateKeyPEM(t))
+	return c
+}
+
+func TestPinnedCertFingerprints(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"banks":[]}`))
+	}))
+	defer srv.Close()
+
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	// match, in upper case with colons.
+	var colons []string
+	for i := 0; i < len(fingerprint); i += 2 {
+		colons = append(colons, strings.ToUpper(fingerprint[i:i+2]))
+	}
+	c := newPinnedTestClient(t, srv, []string{strings.Repeat("00", sha256.Size), strings.Join(colons, ":")})
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatalf("pinned certificate rejected: %v", err)
+	}
+
+	// mismatch
+	c = newPinnedTestClient(t, srv, []string{strings.Repeat("00", sha256.Size)})
+	_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+	if err == nil || !strings.Contains(err.Error(), "is not pinned") {
+		t.Fatalf("got %v, want a pinning error", err)
+	}
+}
+
+func TestPinnedCertFingerprintsOptionsUntouched(t *testing.T) {
+	httpClient := &http.Client{Transport: &http.Transport{}}
+	o := &Options{HTTPClient: httpClient, PinnedCertFingerprints: []string{strings.Repeat("00", sha256.Size)}}
+	for i := 0; i < 2; i++ {
+		c := New(o)
+		if o.HTTPClient != httpClient || c.externalHTTPClient != httpClient {
+			t.Fatalf("client %d: got the pinned client set to the options or used as the external client", i)
+		}
+		if c.httpClient.Transport.(*http.Transport).TLSClientConfig.VerifyPeerCertificate == nil {
+			t.Errorf("client %d: got no pins on the API client", i)
+		}
+	}
+	if tlsConfig := httpClient.Transport.(*http.Transport).TLSClientConfig; tlsConfig != nil && tlsConfig.VerifyPeerCertificate != nil {
+		t.Error("got the pins set on the transport of the options")
+	}
+}
+
+func TestPinnedCertFingerprintsUnsupportedTransport(t *testing.T) {
+	_, err := NewWithOptions(&Options{
+		HTTPClient:             &http.Client{Transport: roundTripFunc(http.DefaultTransport.RoundTrip)},
+		PinnedCertFingerprints: []string{"00"},
+	})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got error %v, want %s", err, ErrInvalidParameter)
+	}
+}
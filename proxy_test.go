@@ -0,0 +1,38 @@
+package wallet
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyURL(t *testing.T) {
+	var method, host, authorization string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, host, authorization = r.Method, r.Host, r.Header.Get("Proxy-Authorization")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer proxy.Close()
+
+	c := New(&Options{ProxyURL: "http://user:secret@" + proxy.Listener.Addr().String()})
+	c.SetCredentials(testKeyID, testECPrivateKeyPEM(t))
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}, WithoutRetry()); err == nil {
+		t.Fatal("got no error, want the proxy to refuse the tunnel")
+	}
+	if method != http.MethodConnect || host != "external-api.wallet.halogen.my:443" {
+		t.Errorf("got %s %s through the proxy, want a tunnel to the API", method, host)
+	}
+	if want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret")); authorization != want {
+		t.Errorf("got Proxy-Authorization %q, want %q", authorization, want)
+	}
+}
+
+func TestProxyURLInvalid(t *testing.T) {
+	var werr Error
+	if _, err := NewWithOptions(&Options{ProxyURL: "proxy.example.com"}); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got error %v for a relative proxy URL, want %s", err, ErrInvalidParameter)
+	}
+}
@@ -0,0 +1,75 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+// publicJWK is the JSON Web Key of a public key, see PublicKeyJWK.
+type publicJWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// PublicKeyJWK returns the public key of the EC or RSA private key privateKeyPEM as a JSON Web Key, for instance,
+// to register a new signing key with the server. Its use is "sig", its alg is the one the client signs with, and
+// its kid is the JWK thumbprint of the key as defined by RFC 7638.
+//
+// It returns [ErrInvalidPrivateKey] when privateKeyPEM cannot be parsed. privateKeyPEM is left as is.
+func PublicKeyJWK(privateKeyPEM []byte) (json.RawMessage, error) {
+	signer, alg, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, Error{Code: ErrInvalidPrivateKey, Message: err.Error()}
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+	key := publicJWK{Use: "sig", Alg: alg}
+	// the thumbprint is the hash of the required members of the key, in lexicographic order
+	var thumbprint string
+	switch privateKey := signer.(type) {
+	case *ecdsa.PrivateKey:
+		size := (privateKey.Curve.Params().BitSize + 7) / 8
+		key.Kty = "EC"
+		key.Crv = privateKey.Curve.Params().Name
+		key.X = encode(privateKey.X.FillBytes(make([]byte, size)))
+		key.Y = encode(privateKey.Y.FillBytes(make([]byte, size)))
+		thumbprint = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q,"y":%q}`, key.Crv, key.Kty, key.X, key.Y)
+	case *rsa.PrivateKey:
+		key.Kty = "RSA"
+		key.N = encode(privateKey.N.Bytes())
+		key.E = encode(big.NewInt(int64(privateKey.E)).Bytes())
+		thumbprint = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, key.E, key.Kty, key.N)
+	}
+	hash := sha256.Sum256([]byte(thumbprint))
+	key.Kid = encode(hash[:])
+	return json.Marshal(key)
+}
+
+// PublicKeyPEM returns the public key of the EC or RSA private key privateKeyPEM, PEM encoded as a "PUBLIC KEY"
+// block, for instance, to register a new signing key with the server.
+//
+// It returns [ErrInvalidPrivateKey] when privateKeyPEM cannot be parsed. privateKeyPEM is left as is.
+func PublicKeyPEM(privateKeyPEM []byte) ([]byte, error) {
+	signer, _, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, Error{Code: ErrInvalidPrivateKey, Message: err.Error()}
+	}
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, Error{Code: ErrInvalidPrivateKey, Message: err.Error()}
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
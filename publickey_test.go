@@ -0,0 +1,114 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// decodeTestJWK decodes the JWK b and checks its kid is its RFC 7638 thumbprint over the members required.
+func decodeTestJWK(t *testing.T, b json.RawMessage, required ...string) map[string]string {
+	t.Helper()
+	var key map[string]string
+	if err := json.Unmarshal(b, &key); err != nil {
+		t.Fatal(err)
+	}
+	members := map[string]string{}
+	for _, name := range required {
+		members[name] = key[name]
+	}
+	canonical, _ := json.Marshal(members)
+	hash := sha256.Sum256(canonical)
+	if want := base64.RawURLEncoding.EncodeToString(hash[:]); key["kid"] != want {
+		t.Errorf("got kid %q, want thumbprint %q", key["kid"], want)
+	}
+	return key
+}
+
+func TestPublicKeyJWK(t *testing.T) {
+	decode := func(s string) *big.Int {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return new(big.Int).SetBytes(b)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalECPrivateKey(ecKey)
+	b, err := PublicKeyJWK(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := decodeTestJWK(t, b, "crv", "kty", "x", "y")
+	if key["kty"] != "EC" || key["crv"] != "P-384" || key["use"] != "sig" || key["alg"] != "ES384" {
+		t.Errorf("got JWK %v", key)
+	}
+	if len(key["x"]) != 64 || decode(key["x"]).Cmp(ecKey.X) != 0 || decode(key["y"]).Cmp(ecKey.Y) != 0 {
+		t.Errorf("got coordinates %s and %s, want the ones of the public key", key["x"], key["y"])
+	}
+	if _, ok := key["d"]; ok {
+		t.Error("got the private key in the JWK")
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = PublicKeyJWK(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key = decodeTestJWK(t, b, "e", "kty", "n")
+	if key["kty"] != "RSA" || key["e"] != "AQAB" || key["use"] != "sig" || key["alg"] != "RS256" || key["crv"] != "" {
+		t.Errorf("got JWK %v", key)
+	}
+	if decode(key["n"]).Cmp(rsaKey.N) != 0 {
+		t.Error("got a modulus other than the one of the public key")
+	}
+
+	var werr Error
+	if _, err := PublicKeyJWK([]byte("not a key")); !errors.As(err, &werr) || werr.Code != ErrInvalidPrivateKey {
+		t.Errorf("got error %v, want %s", err, ErrInvalidPrivateKey)
+	}
+}
+
+func TestPublicKeyPEM(t *testing.T) {
+	privateKeyPEM := testECPrivateKeyPEM(t)
+	block, _ := pem.Decode(privateKeyPEM)
+	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := PublicKeyPEM(privateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ = pem.Decode(b)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		t.Fatalf("got %s, want a PUBLIC KEY block", b)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !privateKey.PublicKey.Equal(publicKey) {
+		t.Error("got a public key other than the one of the private key")
+	}
+	// the private key is left as is.
+	if again, _ := pem.Decode(privateKeyPEM); again == nil || string(again.Bytes) == string(make([]byte, len(again.Bytes))) {
+		t.Error("the private key was altered")
+	}
+}
@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidateEMVCoQR checks payload is a well-formed EMVCo merchant-presented QR payload, such as
+// [DuitnowPayment.QRPayload]: a sequence of tag-length-value data objects ending with the CRC
+// data object "63" whose value is the CRC-16/CCITT-FALSE of the payload preceding it.
+func ValidateEMVCoQR(payload string) error {
+	i := 0
+	for i < len(payload) {
+		if len(payload)-i < 4 {
+			return fmt.Errorf("wallet: EMVCo QR: truncated data object at %d.", i)
+		}
+		tag := payload[i : i+2]
+		length, err := strconv.Atoi(payload[i+2 : i+4])
+		if err != nil || length < 0 {
+			return fmt.Errorf("wallet: EMVCo QR: invalid length of data object %s at %d.", tag, i)
+		}
+		end := i + 4 + length
+		if end > len(payload) {
+			return fmt.Errorf("wallet: EMVCo QR: data object %s at %d exceeds the payload.", tag, i)
+		}
+		if tag == "63" {
+			if length != 4 || end != len(payload) {
+				return fmt.Errorf("wallet: EMVCo QR: CRC must be the last data object with a length of 4.")
+			}
+			want := fmt.Sprintf("%04X", crc16CCITT([]byte(payload[:i+4])))
+			if got := strings.ToUpper(payload[i+4 : end]); got != want {
+				return fmt.Errorf("wallet: EMVCo QR: CRC %s does not match the payload, expected %s.", got, want)
+			}
+			return nil
+		}
+		i = end
+	}
+	return fmt.Errorf("wallet: EMVCo QR: missing CRC data object.")
+}
+
+// crc16CCITT computes the CRC-16/CCITT-FALSE checksum of b as specified by EMVCo.
+func crc16CCITT(b []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, c := range b {
+		crc ^= uint16(c) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
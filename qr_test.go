@@ -0,0 +1,43 @@
+package wallet
+
+import (
+	"fmt"
+	"testing"
+)
+
+// tlv encodes an EMVCo data object.
+func tlv(tag, value string) string {
+	return fmt.Sprintf("%s%02d%s", tag, len(value), value)
+}
+
+// testEMVCoQR returns a valid EMVCo QR payload.
+func testEMVCoQR() string {
+	payload := tlv("00", "01") + tlv("01", "12") +
+		tlv("26", tlv("00", "A0000006150001")+tlv("01", "89000402")) +
+		tlv("53", "458") + tlv("54", "10.00") + tlv("58", "MY") + "6304"
+	return payload + fmt.Sprintf("%04X", crc16CCITT([]byte(payload)))
+}
+
+func TestCRC16CCITT(t *testing.T) {
+	if got := crc16CCITT([]byte("123456789")); got != 0x29B1 {
+		t.Errorf("got %04X, want 29B1", got)
+	}
+}
+
+func TestValidateEMVCoQR(t *testing.T) {
+	valid := testEMVCoQR()
+	if err := ValidateEMVCoQR(valid); err != nil {
+		t.Errorf("valid payload rejected: %v", err)
+	}
+	for name, payload := range map[string]string{
+		"corrupted":   valid[:20] + "X" + valid[21:],
+		"bad crc":     valid[:len(valid)-4] + "0000",
+		"missing crc": valid[:len(valid)-8],
+		"truncated":   valid[:len(valid)-2],
+		"empty":       "",
+	} {
+		if err := ValidateEMVCoQR(payload); err == nil {
+			t.Errorf("%s: payload %q accepted", name, payload)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package wallet
+
+// LocalRequest represents a request as recorded in the ledger of the integration, reconciled with the
+// requests of the server by [ReconcileRequests].
+type LocalRequest struct {
+	// ID specifies the identifier of the request, as returned by the command creating it.
+	ID string
+
+	// Status specifies the status of the request in the ledger, see RequestStatus constants.
+	Status string
+}
+
+// StatusMismatch represents a request whose status differs between the ledger and the server.
+type StatusMismatch struct {
+	// Local specifies the request as recorded in the ledger.
+	Local LocalRequest
+
+	// Remote specifies the request as returned by the server.
+	Remote ClientAccountRequest
+}
+
+// ReconciliationReport represents the discrepancies between the ledger and the server, keyed by request ID.
+type ReconciliationReport struct {
+	// MissingLocally specifies the requests returned by the server but absent from the ledger.
+	MissingLocally map[string]ClientAccountRequest
+
+	// MissingRemotely specifies the requests of the ledger not returned by the server.
+	MissingRemotely map[string]LocalRequest
+
+	// StatusMismatches specifies the requests whose status differs between the ledger and the server.
+	StatusMismatches map[string]StatusMismatch
+}
+
+// Consistent reports whether the report has no discrepancy.
+func (r ReconciliationReport) Consistent() bool {
+	return len(r.MissingLocally) == 0 && len(r.MissingRemotely) == 0 && len(r.StatusMismatches) == 0
+}
+
+// ReconcileRequests compares the requests local of the ledger with the requests remote fetched with
+// [Client.ListClientAccountRequests], for instance, for a nightly reconciliation. remote must then cover
+// the same period and accounts as local, a request outside of it being reported as missing remotely.
+// When a request ID appears several times, the last one wins.
+func ReconcileRequests(local []LocalRequest, remote []ClientAccountRequest) ReconciliationReport {
+	report := ReconciliationReport{
+		MissingLocally:   map[string]ClientAccountRequest{},
+		MissingRemotely:  map[string]LocalRequest{},
+		StatusMismatches: map[string]StatusMismatch{},
+	}
+	locals := make(map[string]LocalRequest, len(local))
+	for _, request := range local {
+		locals[request.ID] = request
+	}
+	remotes := make(map[string]ClientAccountRequest, len(remote))
+	for _, request := range remote {
+		remotes[request.ID] = request
+	}
+	for id, request := range remotes {
+		localRequest, ok := locals[id]
+		if !ok {
+			report.MissingLocally[id] = request
+			continue
+		}
+		if localRequest.Status != request.Status {
+			report.StatusMismatches[id] = StatusMismatch{Local: localRequest, Remote: request}
+		}
+	}
+	for id, request := range locals {
+		if _, ok := remotes[id]; !ok {
+			report.MissingRemotely[id] = request
+		}
+	}
+	return report
+}
@@ -0,0 +1,39 @@
+package wallet
+
+import "testing"
+
+func TestReconcileRequests(t *testing.T) {
+	local := []LocalRequest{
+		{ID: "req_1", Status: RequestStatusCompleted},
+		{ID: "req_2", Status: RequestStatusPending},
+		{ID: "req_local", Status: RequestStatusPending},
+	}
+	remote := []ClientAccountRequest{
+		{ID: "req_1", Status: RequestStatusCompleted},
+		{ID: "req_2", Status: RequestStatusRejected, Amount: 1000},
+		{ID: "req_remote", Status: RequestStatusProcessing},
+	}
+	report := ReconcileRequests(local, remote)
+	if len(report.MissingLocally) != 1 || report.MissingLocally["req_remote"].Status != RequestStatusProcessing {
+		t.Errorf("got missing locally %v, want req_remote", report.MissingLocally)
+	}
+	if len(report.MissingRemotely) != 1 || report.MissingRemotely["req_local"].ID != "req_local" {
+		t.Errorf("got missing remotely %v, want req_local", report.MissingRemotely)
+	}
+	mismatch, ok := report.StatusMismatches["req_2"]
+	if len(report.StatusMismatches) != 1 || !ok || mismatch.Local.Status != RequestStatusPending ||
+		mismatch.Remote.Status != RequestStatusRejected || mismatch.Remote.Amount != 1000 {
+		t.Errorf("got status mismatches %v, want req_2", report.StatusMismatches)
+	}
+	if report.Consistent() {
+		t.Error("got a consistent report, want discrepancies")
+	}
+
+	report = ReconcileRequests(local[:1], remote[:1])
+	if !report.Consistent() {
+		t.Errorf("got report %+v, want consistent", report)
+	}
+	if report = ReconcileRequests(nil, nil); !report.Consistent() {
+		t.Errorf("got report %+v for no requests, want consistent", report)
+	}
+}
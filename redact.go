@@ -0,0 +1,35 @@
+package wallet
+
+import (
+	"net/http"
+	"strings"
+)
+
+// redactedValue replaces the values of sensitive headers, see redactHeader.
+const redactedValue = "[REDACTED]"
+
+// isSensitiveHeader reports whether the header key carries credentials, that is Authorization,
+// Proxy-Authorization or any X-*-Token header.
+func isSensitiveHeader(key string) bool {
+	key = http.CanonicalHeaderKey(key)
+	return key == "Authorization" || key == "Proxy-Authorization" ||
+		strings.HasPrefix(key, "X-") && strings.HasSuffix(key, "-Token")
+}
+
+// redactHeader returns a copy of header whose sensitive values are replaced, so it can be logged
+// or exposed without leaking credentials, unless [Options.UnmaskSensitiveHeaders] is set.
+func (c *Client) redactHeader(header http.Header) http.Header {
+	if c.options.UnmaskSensitiveHeaders {
+		return header
+	}
+	redacted := header.Clone()
+	for key, values := range redacted {
+		if !isSensitiveHeader(key) {
+			continue
+		}
+		for i := range values {
+			values[i] = redactedValue
+		}
+	}
+	return redacted
+}
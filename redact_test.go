@@ -0,0 +1,55 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSensitiveHeadersRedacted(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, unmask := range []bool{false, true} {
+		logs.Reset()
+		var token string
+		c := newTestClient(t, &Options{
+			Debug:                  true,
+			Logger:                 slog.New(slog.NewTextHandler(&logs, nil)),
+			UnmaskSensitiveHeaders: unmask,
+			MaxReadRetry:           2,
+		}, func(req *http.Request) (*http.Response, error) {
+			token = strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+			resp := jsonResponse(http.StatusInternalServerError, map[string]any{"code": ErrInternal, "message": "failed"})
+			resp.Header.Set("X-Session-Token", "session-secret")
+			return resp, nil
+		})
+		var meta ResponseMeta
+		_, err := c.ListBanks(context.Background(), &ListBanksInput{}, WithResponseMeta(&meta), withHeader(http.Header{"X-Api-Token": []string{"api-secret"}}))
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if token == "" {
+			t.Fatal("expected a token")
+		}
+
+		output := logs.String() + err.Error() + strings.Join(meta.Header.Values("X-Session-Token"), ",")
+		for _, secret := range []string{token, "session-secret", "api-secret"} {
+			if got := strings.Contains(output, secret); got != unmask {
+				t.Errorf("unmask %t: got %q exposed %t, want %t", unmask, secret, got, unmask)
+			}
+		}
+		if !unmask && !strings.Contains(logs.String(), "Authorization: [REDACTED]") {
+			t.Errorf("got logs %s, want the Authorization header redacted", logs.String())
+		}
+		if !unmask && meta.Header.Get("X-Session-Token") != redactedValue {
+			t.Errorf("got X-Session-Token %q in the response meta, want %q", meta.Header.Get("X-Session-Token"), redactedValue)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// referenceDataTTL specifies how long reference data fetched for local validation is kept.
+const referenceDataTTL = time.Hour
+
+// referenceData holds reference lists fetched from the server to validate
+// command inputs locally when [Options.ValidateReferenceData] is set.
+type referenceData struct {
+	bankCodes         referenceSet
+	displayCurrencies referenceSet
+}
+
+// referenceSet is a reference list fetched from the server, guarded by its own lock so a slow fetch
+// of a list does not block the validation against the others.
+type referenceSet struct {
+	mu      sync.Mutex
+	values  map[string]struct{}
+	fetched time.Time
+}
+
+// contains reports whether value is in the set, first setting the set to the values returned by fetch
+// when it is not fetched yet or older than referenceDataTTL. fetch is called without holding the lock,
+// so callers are not serialized behind a slow fetch, and only the fetched set is swapped under the lock.
+func (r *referenceSet) contains(value string, fetch func() ([]string, error)) (bool, error) {
+	r.mu.Lock()
+	if r.values != nil && time.Since(r.fetched) <= referenceDataTTL {
+		_, ok := r.values[value]
+		r.mu.Unlock()
+		return ok, nil
+	}
+	r.mu.Unlock()
+
+	values, err := fetch()
+	if err != nil {
+		return false, err
+	}
+	s := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		s[v] = struct{}{}
+	}
+	r.mu.Lock()
+	r.values = s
+	r.fetched = time.Now()
+	r.mu.Unlock()
+	_, ok := s[value]
+	return ok, nil
+}
+
+// validateBankCode checks bankCode against the banks supported by the server.
+func (c *Client) validateBankCode(ctx context.Context, bankCode string) error {
+	if bankCode == "" {
+		return Error{Code: ErrMissingParameter, Message: "wallet: bank code is required."}
+	}
+	ok, err := c.referenceData.bankCodes.contains(bankCode, func() ([]string, error) {
+		output, err := c.ListBanks(ctx, &ListBanksInput{})
+		if err != nil {
+			return nil, err
+		}
+		if output == nil {
+			return nil, Error{Code: ErrInternal, Message: "wallet: no banks returned to validate the bank code."}
+		}
+		bankCodes := make([]string, 0, len(output.Banks))
+		for _, bank := range output.Banks {
+			bankCodes = append(bankCodes, bank.Bic)
+		}
+		return bankCodes, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return Error{Code: ErrInvalidParameter, Message: "wallet: unknown bank code " + bankCode + ". Use ListBanks to retrieve the supported banks."}
+	}
+	return nil
+}
+
+// validateDisplayCurrency checks currency against the display currencies supported by the server.
+func (c *Client) validateDisplayCurrency(ctx context.Context, currency string) error {
+	if currency == "" {
+		return Error{Code: ErrMissingParameter, Message: "wallet: display currency is required."}
+	}
+	ok, err := c.referenceData.displayCurrencies.contains(currency, func() ([]string, error) {
+		output, err := c.ListDisplayCurrencies(ctx, &ListDisplayCurrenciesInput{})
+		if err != nil {
+			return nil, err
+		}
+		if output == nil {
+			return nil, Error{Code: ErrInternal, Message: "wallet: no display currencies returned to validate the display currency."}
+		}
+		currencies := make([]string, 0, len(output.Currencies))
+		for _, currency := range output.Currencies {
+			currencies = append(currencies, currency.ID)
+		}
+		return currencies, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return Error{Code: ErrInvalidParameter, Message: "wallet: unsupported display currency " + currency + ". Use ListDisplayCurrencies to retrieve the supported currencies."}
+	}
+	return nil
+}
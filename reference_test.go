@@ -0,0 +1,84 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReferenceDataFetchedOutsideLock(t *testing.T) {
+	release := make(chan struct{})
+	fetching := make(chan struct{}, 1)
+	c := newTestClient(t, &Options{ValidateReferenceData: true}, func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		fetching <- struct{}{}
+		<-release
+		return jsonResponse(http.StatusOK, map[string]any{"banks": []map[string]any{{"name": "Maybank", "bic": "MBBEMYKL"}}}), nil
+	})
+
+	slow := make(chan error, 1)
+	go func() {
+		slow <- c.validateBankCode(context.Background(), "MBBEMYKL")
+	}()
+	<-fetching
+
+	// a caller whose context is done is not held behind the slow fetch.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.validateBankCode(ctx, "MBBEMYKL")
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("validation blocked behind the slow fetch")
+	}
+
+	close(release)
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReferenceDataSetsLockedSeparately(t *testing.T) {
+	c := New(&Options{})
+	// a fetch of the display currencies in progress holds their lock.
+	c.referenceData.displayCurrencies.mu.Lock()
+	defer c.referenceData.displayCurrencies.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.referenceData.bankCodes.contains("MBBEMYKL", func() ([]string, error) { return []string{"MBBEMYKL"}, nil })
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("bank code validation blocked by the display currencies")
+	}
+}
+
+func TestReferenceDataNullBody(t *testing.T) {
+	c := newTestClient(t, &Options{ValidateReferenceData: true}, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, nil), nil
+	})
+
+	var werr Error
+	if err := c.validateBankCode(context.Background(), "MBBEMYKL"); !errors.As(err, &werr) || werr.Code != ErrInternal {
+		t.Errorf("bank code: got %v, want %s", err, ErrInternal)
+	}
+	if err := c.validateDisplayCurrency(context.Background(), "USD"); !errors.As(err, &werr) || werr.Code != ErrInternal {
+		t.Errorf("display currency: got %v, want %s", err, ErrInternal)
+	}
+}
@@ -0,0 +1,72 @@
+package wallet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// retryBudget is a token bucket limiting the number of retries across all requests of a client.
+// It holds up to capacity tokens and refills them at capacity per window.
+type retryBudget struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	// refillRate is the number of tokens refilled per second.
+	refillRate float64
+	refilledAt time.Time
+}
+
+func newRetryBudget(capacity int, window time.Duration) *retryBudget {
+	return &retryBudget{
+		capacity:   float64(capacity),
+		tokens:     float64(capacity),
+		refillRate: float64(capacity) / window.Seconds(),
+		refilledAt: time.Now(),
+	}
+}
+
+// take reports whether a retry is allowed, consuming a token when it is.
+// A nil budget always allows retrying.
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.refilledAt).Seconds() * b.refillRate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.refilledAt = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// jitterRetryAfter returns the delay to wait before retrying a rate-limited request given retryAfter, the delay
+// requested by the server, capped at max then spread by up to 10% either way, without exceeding max, so clients
+// told to retry at the same time do not burst again together. randInt64N returns a random number in [0, n).
+func jitterRetryAfter(retryAfter time.Duration, max time.Duration, randInt64N func(n int64) int64) time.Duration {
+	delay := min(retryAfter, max)
+	spread := int64(delay / 10)
+	if spread <= 0 {
+		return delay
+	}
+	return min(delay-time.Duration(spread)+time.Duration(randInt64N(2*spread+1)), max)
+}
+
+// sleep waits for d, returning early with the error of ctx when it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
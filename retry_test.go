@@ -0,0 +1,215 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	attempts := 0
+	c := newTestClient(t, &Options{
+		MaxReadRetry:      3,
+		RetryInterval:     time.Millisecond,
+		RetryBudget:       2,
+		RetryBudgetWindow: time.Hour,
+	}, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return jsonResponse(http.StatusInternalServerError, map[string]any{"code": ErrInternal, "message": "internal error"}), nil
+	})
+
+	// the first call retries twice, depleting the budget.
+	_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInternal {
+		t.Fatalf("got %v, want %s", err, ErrInternal)
+	}
+	if attempts != 3 {
+		t.Fatalf("got %d attempts, want 3", attempts)
+	}
+
+	// the second call fails immediately.
+	_, err = c.ListBanks(context.Background(), &ListBanksInput{})
+	if !errors.As(err, &werr) || werr.Code != ErrRetryBudgetExhausted || werr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("got %v, want %s", err, ErrRetryBudgetExhausted)
+	}
+	if attempts != 4 {
+		t.Errorf("got %d attempts, want 4", attempts)
+	}
+}
+
+func TestRetryBudgetRefills(t *testing.T) {
+	b := newRetryBudget(1, 10*time.Millisecond)
+	if !b.take() {
+		t.Fatal("expected a retry to be allowed")
+	}
+	if b.take() {
+		t.Fatal("expected the budget to be depleted")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !b.take() {
+		t.Fatal("expected the budget to be refilled")
+	}
+}
+
+func TestMaintenanceIsNotRetried(t *testing.T) {
+	attempts := 0
+	c := newTestClient(t, &Options{MaxReadRetry: 5, RetryInterval: time.Millisecond}, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return jsonResponse(http.StatusServiceUnavailable, map[string]any{
+			"code":    "maintenance",
+			"message": "scheduled maintenance",
+			"until":   "2025-03-01T02:00:00Z",
+		}), nil
+	})
+
+	_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrMaintenance || werr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got %v, want %s", err, ErrMaintenance)
+	}
+	if want := time.Date(2025, 3, 1, 2, 0, 0, 0, time.UTC); !werr.Until.Equal(want) {
+		t.Errorf("got until %s, want %s", werr.Until, want)
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+}
+
+func TestRetryLogging(t *testing.T) {
+	// keep the debug dumps of requests and responses out of the test output.
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	var logs bytes.Buffer
+	attempts := 0
+	c := newTestClient(t, &Options{
+		Debug:         true,
+		Logger:        slog.New(slog.NewJSONHandler(&logs, nil)),
+		MaxReadRetry:  3,
+		RetryInterval: time.Millisecond,
+	}, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return jsonResponse(http.StatusInternalServerError, map[string]any{"code": ErrInternal}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	var line map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+		t.Fatalf("got logs %q, want a single JSON line: %v", logs.String(), err)
+	}
+	for key, want := range map[string]any{
+		"msg":       "wallet: retrying request",
+		"operation": "list_banks",
+		"attempt":   float64(2),
+		"status":    float64(http.StatusInternalServerError),
+		"code":      ErrInternal,
+		"reason":    "server_error",
+		"delay":     float64(time.Millisecond),
+	} {
+		if line[key] != want {
+			t.Errorf("got %s %v, want %v", key, line[key], want)
+		}
+	}
+}
+
+func TestNonRetryableCodes(t *testing.T) {
+	attempts := 0
+	c := newTestClient(t, &Options{
+		MaxReadRetry:      5,
+		RetryInterval:     time.Millisecond,
+		NonRetryableCodes: []string{"insufficient_funds"},
+	}, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return jsonResponse(http.StatusInternalServerError, map[string]any{"code": "insufficient_funds"}), nil
+	})
+
+	_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != "insufficient_funds" {
+		t.Fatalf("got %v, want insufficient_funds", err)
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+}
+
+func TestJitterRetryAfter(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		delay := jitterRetryAfter(10*time.Second, time.Minute, mathrand.Int64N)
+		if delay < 9*time.Second || delay > 11*time.Second {
+			t.Fatalf("got delay %s, want 10s ± 10%%", delay)
+		}
+	}
+	// the jitter is applied.
+	lowest := jitterRetryAfter(10*time.Second, time.Minute, func(n int64) int64 { return 0 })
+	highest := jitterRetryAfter(10*time.Second, time.Minute, func(n int64) int64 { return n - 1 })
+	if lowest != 9*time.Second || highest != 11*time.Second {
+		t.Errorf("got delays from %s to %s, want from 9s to 11s", lowest, highest)
+	}
+
+	// the cap is enforced.
+	for i := 0; i < 1000; i++ {
+		delay := jitterRetryAfter(86400*time.Second, time.Minute, mathrand.Int64N)
+		if delay < 54*time.Second || delay > time.Minute {
+			t.Fatalf("got delay %s, want at most 1m", delay)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	type call struct {
+		attempt int
+		status  int
+		code    string
+	}
+	var calls []call
+	attempts := 0
+	c := newTestClient(t, &Options{
+		ShouldRetry: func(attempt int, resp *http.Response, err error) (bool, time.Duration) {
+			var werr Error
+			errors.As(err, &werr)
+			calls = append(calls, call{attempt, resp.StatusCode, werr.Code})
+			return werr.Code == "stale_read", time.Millisecond
+		},
+	}, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return jsonResponse(http.StatusBadRequest, map[string]any{"code": "stale_read"}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+
+	if _, err := c.ListBanks(context.Background(), &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []call{{1, http.StatusBadRequest, "stale_read"}}; len(calls) != 1 || calls[0] != want[0] {
+		t.Errorf("got calls %+v, want %+v", calls, want)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+
+	// commands are not retried.
+	attempts = 0
+	var output map[string]any
+	err := c.command(context.Background(), "test", map[string]any{}, &output)
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != "stale_read" || attempts != 1 {
+		t.Errorf("got %v after %d attempts, want stale_read after 1", err, attempts)
+	}
+}
@@ -0,0 +1,97 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// queryOperations lists the operations of the query APIs, probed by [Client.GetAuthorizedOperations].
+var queryOperations = []Operation{
+	OperationGetClientAccountAllocationPerformance,
+	OperationGetClientAccountRequestConfirmation,
+	OperationGetClientAccountRequestPolicy,
+	OperationGetClientAccountStatement,
+	OperationGetClientProfile,
+	OperationGetClientReferral,
+	OperationGetFund,
+	OperationGetGoalProjection,
+	OperationGetJointInvitationStatus,
+	OperationGetPreviewInvest,
+	OperationGetProjectedFundPrice,
+	OperationGetRequestByDuitNowEndToEndID,
+	OperationGetVoucher,
+	OperationListBanks,
+	OperationListClientAccountBalance,
+	OperationListClientAccountMandateRequests,
+	OperationListClientAccountPerformance,
+	OperationListClientAccountRequests,
+	OperationListClientAccounts,
+	OperationListClientBankAccounts,
+	OperationListClientPromos,
+	OperationListClientSuitabilityAssessments,
+	OperationListDisplayCurrencies,
+	OperationListDuitNowBanks,
+	OperationListFundsForSubscription,
+	OperationListInvestConsents,
+	OperationListPaymentMethods,
+}
+
+// AuthorizedOperations reports the operations the credentials of a client are authorized for,
+// see [Client.GetAuthorizedOperations].
+type AuthorizedOperations struct {
+	// Authorized lists the probed operations the credentials are authorized for.
+	Authorized []Operation
+	// Unauthorized lists the probed operations rejected with [ErrInsufficientAccess].
+	Unauthorized []Operation
+}
+
+// Allows reports whether operation is authorized.
+func (a *AuthorizedOperations) Allows(operation Operation) bool {
+	return slices.Contains(a.Authorized, operation)
+}
+
+// GetAuthorizedOperations probes the query operations the credentials are authorized for, as the server does not
+// expose the scope of credentials. Each of operations, or every query operation when empty, is sent once with an
+// empty payload and without retry. It is unauthorized when rejected with [ErrInsufficientAccess], and authorized
+// when the server responds anything else, typically [ErrMissingParameter] as authorization is checked first.
+// It is meant to be run in deployment smoke tests rather than before each call.
+//
+// Commands cannot be probed without side effects, they fail with [ErrInvalidParameter].
+//
+// Errors:
+//   - [ErrInvalidParameter]
+//   - [ErrInvalidAuthSignature]
+//   - [ErrInternal]
+func (c *Client) GetAuthorizedOperations(ctx context.Context, operations []Operation, opts ...CallOption) (*AuthorizedOperations, error) {
+	if len(operations) == 0 {
+		operations = queryOperations
+	}
+	for _, operation := range operations {
+		if !slices.Contains(queryOperations, operation) {
+			return nil, Error{Code: ErrInvalidParameter, Message: "wallet: operation " + string(operation) + " is not a query and cannot be probed."}
+		}
+	}
+	opts = append(opts, WithoutRetry())
+	result := &AuthorizedOperations{}
+	for _, operation := range operations {
+		var output json.RawMessage
+		err := c.query(ctx, operation, struct{}{}, &output, opts...)
+		var werr Error
+		switch {
+		case err == nil:
+			result.Authorized = append(result.Authorized, operation)
+		case errors.As(err, &werr) && werr.Code == ErrInsufficientAccess:
+			result.Unauthorized = append(result.Unauthorized, operation)
+		// the request passed the authorization but not the validation of its payload.
+		case errors.As(err, &werr) && werr.StatusCode >= http.StatusBadRequest && werr.StatusCode < http.StatusInternalServerError &&
+			werr.StatusCode != http.StatusUnauthorized && werr.StatusCode != http.StatusTooManyRequests:
+			result.Authorized = append(result.Authorized, operation)
+		default:
+			return nil, err
+		}
+	}
+	return result, nil
+}
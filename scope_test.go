@@ -0,0 +1,68 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestGetAuthorizedOperations(t *testing.T) {
+	scope := []Operation{OperationListClientAccounts, OperationGetFund, OperationListBanks}
+	probed := 0
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		probed++
+		r := decodeTestRequest(t, req)
+		if string(r.Payload) != "{}" {
+			t.Errorf("got payload %s, want {}", r.Payload)
+		}
+		switch operation := Operation(r.Name); {
+		case !slices.Contains(scope, operation):
+			return jsonResponse(http.StatusForbidden, map[string]any{"code": ErrInsufficientAccess}), nil
+		case operation == OperationGetFund:
+			return jsonResponse(http.StatusBadRequest, map[string]any{"code": ErrMissingParameter}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+	ctx := context.Background()
+
+	result, err := c.GetAuthorizedOperations(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if probed != len(queryOperations) || len(result.Authorized)+len(result.Unauthorized) != len(queryOperations) {
+		t.Errorf("probed %d operations, got %d authorized and %d unauthorized, want %d", probed, len(result.Authorized), len(result.Unauthorized), len(queryOperations))
+	}
+	for _, operation := range queryOperations {
+		if got, want := result.Allows(operation), slices.Contains(scope, operation); got != want {
+			t.Errorf("got %s allowed %t, want %t", operation, got, want)
+		}
+	}
+
+	result, err = c.GetAuthorizedOperations(ctx, []Operation{OperationGetVoucher, OperationListBanks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(result.Authorized, []Operation{OperationListBanks}) || !slices.Equal(result.Unauthorized, []Operation{OperationGetVoucher}) {
+		t.Errorf("got %+v", result)
+	}
+
+	// commands are not probed.
+	_, err = c.GetAuthorizedOperations(ctx, []Operation{OperationCreateInvestmentRequest})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got %v, want %s", err, ErrInvalidParameter)
+	}
+}
+
+func TestGetAuthorizedOperationsInvalidCredentials(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusUnauthorized, map[string]any{"code": ErrInvalidAuthSignature}), nil
+	})
+	_, err := c.GetAuthorizedOperations(context.Background(), nil)
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidAuthSignature {
+		t.Errorf("got %v, want %s", err, ErrInvalidAuthSignature)
+	}
+}
@@ -0,0 +1,231 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// responseSignatureHeader is the header carrying the signature of the response body.
+	responseSignatureHeader string = "X-Signature"
+	// responseSignatureKeyIDHeader is the header identifying the key of the JWKS that signed the response.
+	responseSignatureKeyIDHeader string = "X-Signature-Key-Id"
+)
+
+const (
+	// jwksMaxBytes specifies the maximum size of the JWKS fetched from Options.JWKSURL.
+	jwksMaxBytes = 1 << 20
+	// jwksRefetchInterval specifies the minimum delay between two fetches of the JWKS upon an unknown key ID,
+	// for instance, after the server rotated its signing key.
+	jwksRefetchInterval = time.Minute
+)
+
+// responseVerifier verifies the signature of successful responses, see [Options.ResponsePublicKeyPEM]
+// and [Options.JWKSURL].
+type responseVerifier struct {
+	// publicKey is the static key, nil when the keys are fetched from jwksURL.
+	publicKey crypto.PublicKey
+	jwksURL   string
+	jwksTTL   time.Duration
+	client    *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// newResponseVerifier returns the verifier configured by o, nil when responses are not verified. The JWKS
+// is fetched with client.
+//
+// ResponsePublicKeyPEM must be valid, see parsePublicKeyPEM.
+func newResponseVerifier(o *Options, client *http.Client) *responseVerifier {
+	if len(o.ResponsePublicKeyPEM) > 0 {
+		publicKey, _ := parsePublicKeyPEM(o.ResponsePublicKeyPEM)
+		return &responseVerifier{publicKey: publicKey}
+	}
+	if o.JWKSURL != "" {
+		return &responseVerifier{jwksURL: o.JWKSURL, jwksTTL: o.JWKSCacheTTL, client: client}
+	}
+	return nil
+}
+
+// parsePublicKeyPEM parses a PEM encoded PKIX public key.
+func parsePublicKeyPEM(publicKeyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.New("not in PEM format")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// verify checks the signature of the body of resp, which is read and replaced so the caller can still read it.
+// A nil verifier verifies nothing.
+func (v *responseVerifier) verify(ctx context.Context, resp *http.Response) error {
+	if v == nil {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	invalid := func(reason string) error {
+		return Error{StatusCode: resp.StatusCode, Code: ErrResponseSignatureInvalid, Message: "wallet: invalid response signature, " + reason + "."}
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(resp.Header.Get(responseSignatureHeader))
+	if err != nil || len(signature) == 0 {
+		return invalid("missing or malformed " + responseSignatureHeader + " header")
+	}
+	publicKey := v.publicKey
+	if publicKey == nil {
+		keyID := resp.Header.Get(responseSignatureKeyIDHeader)
+		if publicKey, err = v.jwksKey(ctx, keyID); err != nil {
+			return err
+		}
+		if publicKey == nil {
+			return invalid(fmt.Sprintf("unknown key %q", keyID))
+		}
+	}
+	digest := sha256.Sum256(body)
+	var ok bool
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(key, digest[:], signature)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return invalid(fmt.Sprintf("unsupported key type %T", publicKey))
+	}
+	if !ok {
+		return invalid("the body does not match its signature")
+	}
+	return nil
+}
+
+// jwksKey returns the key identified by keyID in the JWKS, fetched when not fetched yet or older than the TTL,
+// and refetched upon an unknown key ID at most every jwksRefetchInterval. The key is nil when the JWKS does
+// not contain it.
+func (v *responseVerifier) jwksKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	stale := v.keys == nil || time.Since(v.keysFetched) > v.jwksTTL
+	if !stale {
+		if key, ok := v.keys[keyID]; ok {
+			return key, nil
+		}
+		stale = time.Since(v.keysFetched) > jwksRefetchInterval
+	}
+	if stale {
+		keys, err := v.fetchJWKS(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+		v.keysFetched = time.Now()
+	}
+	return v.keys[keyID], nil
+}
+
+// jwk is a JSON Web Key, only EC P-256 and RSA keys are supported.
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (v *responseVerifier) fetchJWKS(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, Error{Code: ErrJWKSUnavailable, Message: fmt.Sprintf("wallet: failed to fetch JWKS. err=%v", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, Error{StatusCode: resp.StatusCode, Code: ErrJWKSUnavailable, Message: fmt.Sprintf("wallet: failed to fetch JWKS, got status code %d.", resp.StatusCode)}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, jwksMaxBytes+1))
+	if err != nil {
+		return nil, Error{Code: ErrJWKSUnavailable, Message: fmt.Sprintf("wallet: failed to read JWKS. err=%v", err)}
+	}
+	if len(body) > jwksMaxBytes {
+		return nil, Error{Code: ErrJWKSUnavailable, Message: fmt.Sprintf("wallet: JWKS exceeds %d bytes.", jwksMaxBytes)}
+	}
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return nil, Error{Code: ErrJWKSUnavailable, Message: fmt.Sprintf("wallet: failed to decode JWKS. err=%v", err)}
+	}
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		publicKey, err := k.publicKey()
+		if err != nil {
+			// keys of an unsupported type do not prevent using the others.
+			continue
+		}
+		keys[k.Kid] = publicKey
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	switch {
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !key.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("wallet: JWK %s is not on the curve", k.Kid)
+		}
+		return key, nil
+	case k.Kty == "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	}
+	return nil, fmt.Errorf("wallet: unsupported JWK type %s", k.Kty)
+}
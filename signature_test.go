@@ -0,0 +1,193 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// signedResponse returns a successful response with body signed by key, and the body altered when tamper is set.
+func signedResponse(t *testing.T, key *ecdsa.PrivateKey, keyID string, body []byte, tamper bool) *http.Response {
+	t.Helper()
+	digest := sha256.Sum256(body)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tamper {
+		body = bytes.Replace(body, []byte("MBBEMYKL"), []byte("EVILMYKL"), 1)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Content-Type":       []string{"application/json"},
+			"X-Signature":        []string{base64.RawURLEncoding.EncodeToString(signature)},
+			"X-Signature-Key-Id": []string{keyID},
+		},
+		Body: io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+func TestResponseSignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]any{{
+		"kid": "server-1",
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}}})
+	body := []byte(`{"banks":[{"name":"Maybank","bic":"MBBEMYKL"}]}`)
+
+	for _, tt := range []struct {
+		name string
+		o    *Options
+	}{
+		{"static key", &Options{ResponsePublicKeyPEM: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})}},
+		{"JWKS", &Options{JWKSURL: "https://auth.example.com/.well-known/jwks.json"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tamper := false
+			jwksFetches := 0
+			c := newTestClient(t, tt.o, func(req *http.Request) (*http.Response, error) {
+				if req.URL.Host == "auth.example.com" {
+					jwksFetches++
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(jwks))}, nil
+				}
+				return signedResponse(t, key, "server-1", body, tamper), nil
+			})
+
+			for i := 0; i < 2; i++ {
+				output, err := c.ListBanks(context.Background(), &ListBanksInput{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(output.Banks) != 1 || output.Banks[0].Bic != "MBBEMYKL" {
+					t.Errorf("got banks %+v", output.Banks)
+				}
+			}
+			if tt.o.JWKSURL != "" && jwksFetches != 1 {
+				t.Errorf("got %d JWKS fetches, want 1", jwksFetches)
+			}
+
+			tamper = true
+			_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+			var werr Error
+			if !errors.As(err, &werr) || werr.Code != ErrResponseSignatureInvalid {
+				t.Errorf("got %v, want %s", err, ErrResponseSignatureInvalid)
+			}
+		})
+	}
+}
+
+// testJWKS returns the JWKS of the EC P-256 keys by key ID.
+func testJWKS(keys map[string]*ecdsa.PrivateKey) []byte {
+	var jwks []map[string]any
+	for kid, key := range keys {
+		jwks = append(jwks, map[string]any{
+			"kid": kid,
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+	b, _ := json.Marshal(map[string]any{"keys": jwks})
+	return b
+}
+
+func TestResponseSignatureJWKSRotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys := map[string]*ecdsa.PrivateKey{"server-1": oldKey}
+	signingKeyID, signingKey := "server-1", oldKey
+	jwksFetches, middlewareCalls := 0, 0
+	c := newTestClient(t, &Options{
+		JWKSURL: "https://auth.example.com/.well-known/jwks.json",
+		Middlewares: []func(http.RoundTripper) http.RoundTripper{func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				middlewareCalls++
+				return next.RoundTrip(req)
+			})
+		}},
+	}, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "auth.example.com" {
+			jwksFetches++
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(testJWKS(keys)))}, nil
+		}
+		return signedResponse(t, signingKey, signingKeyID, []byte(`{"banks":[]}`), false), nil
+	})
+	ctx := context.Background()
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the server rotates its key, the JWKS was just fetched and is not refetched yet.
+	keys["server-2"] = newKey
+	signingKeyID, signingKey = "server-2", newKey
+	_, err := c.ListBanks(ctx, &ListBanksInput{})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrResponseSignatureInvalid {
+		t.Errorf("got %v, want %s", err, ErrResponseSignatureInvalid)
+	}
+	// once the refetch interval elapsed, the unknown key is fetched before the TTL.
+	c.responseVerifier.keysFetched = time.Now().Add(-2 * jwksRefetchInterval)
+	if _, err := c.ListBanks(ctx, &ListBanksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if jwksFetches != 2 {
+		t.Errorf("got %d JWKS fetches, want 2", jwksFetches)
+	}
+	// the JWKS is fetched without the middlewares of the API.
+	if middlewareCalls != 3 {
+		t.Errorf("got %d requests through the middlewares, want 3", middlewareCalls)
+	}
+}
+
+func TestResponseSignatureJWKSUnavailable(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		resp func() (*http.Response, error)
+	}{
+		{"transport error", func() (*http.Response, error) { return nil, errors.New("connection refused") }},
+		{"server error", func() (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusInternalServerError, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		}},
+		{"too large", func() (*http.Response, error) {
+			body := append([]byte(`{"keys":[],"padding":"`), bytes.Repeat([]byte("a"), jwksMaxBytes)...)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(append(body, `"}`...)))}, nil
+		}},
+	} {
+		c := newTestClient(t, &Options{JWKSURL: "https://auth.example.com/.well-known/jwks.json"}, func(req *http.Request) (*http.Response, error) {
+			if req.URL.Host == "auth.example.com" {
+				return tt.resp()
+			}
+			key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			return signedResponse(t, key, "server-1", []byte(`{"banks":[]}`), false), nil
+		})
+		_, err := c.ListBanks(context.Background(), &ListBanksInput{})
+		var werr Error
+		if !errors.As(err, &werr) || werr.Code != ErrJWKSUnavailable {
+			t.Errorf("%s: got %v, want %s", tt.name, err, ErrJWKSUnavailable)
+		}
+	}
+}
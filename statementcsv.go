@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Columns of the CSV written by [Client.ExportStatementCSV], named after the fields of [StatementTransaction].
+const (
+	StatementColumnID                string = "id"
+	StatementColumnType              string = "type"
+	StatementColumnDate              string = "date"
+	StatementColumnFundID            string = "fundId"
+	StatementColumnFundName          string = "fundName"
+	StatementColumnFundClassSequence string = "fundClassSequence"
+	StatementColumnAsset             string = "asset"
+	StatementColumnAmount            string = "amount"
+	StatementColumnUnits             string = "units"
+	StatementColumnUnitPrice         string = "unitPrice"
+	StatementColumnFeeAmount         string = "feeAmount"
+)
+
+// statementColumns formats the value of each column of a transaction.
+var statementColumns = map[string]func(tx *StatementTransaction, dateFormat string) string{
+	StatementColumnID:   func(tx *StatementTransaction, _ string) string { return tx.ID },
+	StatementColumnType: func(tx *StatementTransaction, _ string) string { return tx.Type },
+	StatementColumnDate: func(tx *StatementTransaction, dateFormat string) string {
+		if tx.Date.IsZero() {
+			return ""
+		}
+		return tx.Date.Format(dateFormat)
+	},
+	StatementColumnFundID:   func(tx *StatementTransaction, _ string) string { return tx.FundID },
+	StatementColumnFundName: func(tx *StatementTransaction, _ string) string { return tx.FundName },
+	StatementColumnFundClassSequence: func(tx *StatementTransaction, _ string) string {
+		return strconv.Itoa(tx.FundClassSequence)
+	},
+	StatementColumnAsset:     func(tx *StatementTransaction, _ string) string { return tx.Asset },
+	StatementColumnAmount:    func(tx *StatementTransaction, _ string) string { return formatAmount(tx.Amount) },
+	StatementColumnUnits:     func(tx *StatementTransaction, _ string) string { return strconv.FormatFloat(tx.Units, 'f', -1, 64) },
+	StatementColumnUnitPrice: func(tx *StatementTransaction, _ string) string { return strconv.FormatFloat(tx.UnitPrice, 'f', -1, 64) },
+	StatementColumnFeeAmount: func(tx *StatementTransaction, _ string) string { return formatAmount(tx.FeeAmount) },
+}
+
+// formatAmount formats amount as a [Decimal], with 2 decimals.
+func formatAmount(amount float64) string {
+	return NewDecimal(amount).String()
+}
+
+// StatementCSVOptions configures [Client.ExportStatementCSV].
+type StatementCSVOptions struct {
+	// Columns specifies the columns written, in order, see StatementColumn constants.
+	//
+	// Optional, defaulted to date, id, type, fundName, amount, units, unitPrice and feeAmount.
+	Columns []string
+
+	// DateFormat specifies the layout of the dates, see [time.Layout].
+	//
+	// Optional, defaulted to [time.DateOnly].
+	DateFormat string
+}
+
+// ExportStatementCSV writes the transactions of the account statement to w in CSV format, a header row with
+// the names of the columns followed by a line per transaction, and returns the number of bytes written to w.
+// Unlike [Client.WriteClientAccountStatementCSV], which passes the CSV of the server through as is, the CSV
+// is written by the client from [Client.StreamClientAccountStatement], so that its columns and date format
+// can be chosen. Amounts are formatted as a [Decimal], with 2 decimals.
+//
+// Errors are the ones of [Client.StreamClientAccountStatement], and [ErrInvalidParameter] when a column is unknown,
+// in which case nothing is written to w.
+func (c *Client) ExportStatementCSV(ctx context.Context, input *GetClientAccountStatementInput, w io.Writer, opts StatementCSVOptions, callOpts ...CallOption) (written int64, err error) {
+	if input == nil {
+		return 0, errMissingInput
+	}
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = []string{
+			StatementColumnDate,
+			StatementColumnID,
+			StatementColumnType,
+			StatementColumnFundName,
+			StatementColumnAmount,
+			StatementColumnUnits,
+			StatementColumnUnitPrice,
+			StatementColumnFeeAmount,
+		}
+	}
+	formats := make([]func(tx *StatementTransaction, dateFormat string) string, len(columns))
+	for i, column := range columns {
+		format, ok := statementColumns[column]
+		if !ok {
+			return 0, Error{Code: ErrInvalidParameter, Message: "wallet: unknown statement column " + column + "."}
+		}
+		formats[i] = format
+	}
+	dateFormat := opts.DateFormat
+	if dateFormat == "" {
+		dateFormat = time.DateOnly
+	}
+
+	counter := &countingWriter{w: w}
+	cw := csv.NewWriter(counter)
+	if err := cw.Write(columns); err != nil {
+		return counter.n, err
+	}
+	record := make([]string, len(columns))
+	err = c.StreamClientAccountStatement(ctx, input, func(tx StatementTransaction) error {
+		for i, format := range formats {
+			record[i] = format(&tx, dateFormat)
+		}
+		return cw.Write(record)
+	}, callOpts...)
+	if err != nil {
+		return counter.n, err
+	}
+	cw.Flush()
+	return counter.n, cw.Error()
+}
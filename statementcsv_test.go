@@ -0,0 +1,72 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newStatementTestClient(t *testing.T) *Client {
+	t.Helper()
+	return newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, map[string]any{
+			"fromDate": "2024-01-01",
+			"transactions": []map[string]any{
+				{"id": "tx_1", "type": "investment", "date": "2024-01-02T03:04:05Z", "fundName": "Halogen, Income Fund", "amount": 1000, "units": 998.5, "unitPrice": 1.0015, "feeAmount": 0.125},
+				{"id": "tx_2", "type": "redemption", "date": "2024-01-31T16:00:00Z", "fundName": "Halogen Bond Fund", "amount": -250.456, "units": -250, "unitPrice": 1.001824, "feeAmount": 1.005},
+			},
+		}), nil
+	})
+}
+
+func TestExportStatementCSV(t *testing.T) {
+	c := newStatementTestClient(t)
+	var b strings.Builder
+	written, err := c.ExportStatementCSV(context.Background(), &GetClientAccountStatementInput{AccountID: "acc_1"}, &b, StatementCSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "date,id,type,fundName,amount,units,unitPrice,feeAmount\n" +
+		"2024-01-02,tx_1,investment,\"Halogen, Income Fund\",1000.00,998.5,1.0015,0.13\n" +
+		"2024-01-31,tx_2,redemption,Halogen Bond Fund,-250.46,-250,1.001824,1.01\n"
+	if b.String() != want || written != int64(len(want)) {
+		t.Errorf("got CSV of %d bytes\n%s\nwant\n%s", written, b.String(), want)
+	}
+
+	b.Reset()
+	_, err = c.ExportStatementCSV(context.Background(), &GetClientAccountStatementInput{AccountID: "acc_1"}, &b, StatementCSVOptions{
+		Columns:    []string{StatementColumnID, StatementColumnDate, StatementColumnAmount},
+		DateFormat: "02/01/2006 15:04",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "id,date,amount\ntx_1,02/01/2024 03:04,1000.00\ntx_2,31/01/2024 16:00,-250.46\n"
+	if b.String() != want {
+		t.Errorf("got CSV\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestExportStatementCSVInvalidInput(t *testing.T) {
+	c := newStatementTestClient(t)
+	for _, tt := range []struct {
+		input    *GetClientAccountStatementInput
+		columns  []string
+		wantCode string
+	}{
+		{&GetClientAccountStatementInput{}, []string{"balance"}, ErrInvalidParameter},
+		{nil, nil, ErrMissingParameter},
+	} {
+		var b strings.Builder
+		_, err := c.ExportStatementCSV(context.Background(), tt.input, &b, StatementCSVOptions{Columns: tt.columns})
+		var werr Error
+		if !errors.As(err, &werr) || werr.Code != tt.wantCode {
+			t.Errorf("got error %v, want %s", err, tt.wantCode)
+		}
+		if b.Len() != 0 {
+			t.Errorf("got CSV %q, want nothing written", b.String())
+		}
+	}
+}
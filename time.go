@@ -0,0 +1,115 @@
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// WalletTime is a timestamp returned by the server.
+//
+// The server always returns timestamps in UTC. Upon decoding a response, the client
+// presents every WalletTime in [Options.Location] while preserving the instant.
+type WalletTime struct {
+	time.Time
+}
+
+// walletTimeLayouts lists the layouts accepted when decoding a WalletTime.
+var walletTimeLayouts = []string{
+	time.RFC3339Nano,
+	time.DateTime,
+	time.DateOnly,
+}
+
+func (t *WalletTime) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if len(b) < 2 || b[0] != '"' || b[len(b)-1] != '"' {
+		return fmt.Errorf("wallet: WalletTime: expected a JSON string, got %s", b)
+	}
+	s := string(b[1 : len(b)-1])
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := parseWalletTime(s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// parseWalletTime parses s in one of walletTimeLayouts, in UTC unless s specifies an offset.
+func parseWalletTime(s string) (time.Time, error) {
+	for _, layout := range walletTimeLayouts {
+		parsed, err := time.ParseInLocation(layout, s, time.UTC)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("wallet: WalletTime: unable to parse %q as a timestamp.", s)
+}
+
+func (t WalletTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + t.Format(time.RFC3339Nano) + `"`), nil
+}
+
+// validateDateRange checks from and to are dates, or timestamps, and from is not after to.
+func validateDateRange(from, to string) error {
+	fromTime, err := parseWalletTime(from)
+	if err != nil {
+		return Error{Code: ErrInvalidParameter, Message: fmt.Sprintf("wallet: from date %q is not a valid date.", from)}
+	}
+	toTime, err := parseWalletTime(to)
+	if err != nil {
+		return Error{Code: ErrInvalidParameter, Message: fmt.Sprintf("wallet: to date %q is not a valid date.", to)}
+	}
+	if fromTime.After(toTime) {
+		return Error{Code: ErrInvalidDateRange, Message: fmt.Sprintf("wallet: from date %s is after to date %s.", from, to)}
+	}
+	return nil
+}
+
+var walletTimeType = reflect.TypeOf(WalletTime{})
+
+// localizeTimes walks v and presents every WalletTime found in loc.
+func localizeTimes(v any, loc *time.Location) {
+	if v == nil || loc == nil {
+		return
+	}
+	localizeValue(reflect.ValueOf(v), loc)
+}
+
+func localizeValue(v reflect.Value, loc *time.Location) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			localizeValue(v.Elem(), loc)
+		}
+	case reflect.Struct:
+		if v.Type() == walletTimeType {
+			if v.CanSet() {
+				t := v.Addr().Interface().(*WalletTime)
+				if !t.IsZero() {
+					t.Time = t.In(loc)
+				}
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				localizeValue(v.Field(i), loc)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			localizeValue(v.Index(i), loc)
+		}
+	}
+}
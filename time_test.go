@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWalletTimeLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Kuala_Lumpur")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	c := newTestClient(t, &Options{Location: loc}, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, map[string]any{
+			"createdAt": "2025-01-02T03:04:05Z",
+			"items":     []map[string]any{{"at": "2025-01-02T16:00:00Z"}},
+		}), nil
+	})
+
+	var output *struct {
+		CreatedAt WalletTime `json:"createdAt"`
+		Items     []struct {
+			At *WalletTime `json:"at"`
+		} `json:"items"`
+	}
+	if err := c.query(context.Background(), "test", struct{}{}, &output); err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !output.CreatedAt.Equal(want) {
+		t.Errorf("instant changed: got %v, want %v", output.CreatedAt, want)
+	}
+	if output.CreatedAt.Location() != loc {
+		t.Errorf("got location %v, want %v", output.CreatedAt.Location(), loc)
+	}
+	if output.CreatedAt.Hour() != 11 {
+		t.Errorf("got hour %d, want 11", output.CreatedAt.Hour())
+	}
+	if got := output.Items[0].At; got.Location() != loc || got.Day() != 3 {
+		t.Errorf("nested time not localized: %v", got)
+	}
+}
+
+func TestWalletTimeDefaultsToUTC(t *testing.T) {
+	var wt WalletTime
+	if err := wt.UnmarshalJSON([]byte(`"2025-01-02T03:04:05+08:00"`)); err != nil {
+		t.Fatal(err)
+	}
+	localizeTimes(&wt, New().options.Location)
+	if wt.Location() != time.UTC || wt.Hour() != 19 {
+		t.Errorf("got %v, want UTC 19:04:05", wt)
+	}
+}
@@ -0,0 +1,103 @@
+package wallet
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Trace holds the connection-level timings of a request attempt, see [WithTrace]. Timings of phases
+// that did not happen, for instance, the DNS lookup and the connection when a connection is reused,
+// are zero.
+type Trace struct {
+	// DNSLookup specifies the duration of the DNS lookup.
+	DNSLookup time.Duration
+	// Connect specifies the duration of the TCP connection establishment.
+	Connect time.Duration
+	// TLSHandshake specifies the duration of the TLS handshake.
+	TLSHandshake time.Duration
+	// TimeToFirstByte specifies the duration from the start of the attempt to the first byte of the response.
+	TimeToFirstByte time.Duration
+	// Total specifies the duration from the start of the attempt to the response headers, or the error.
+	Total time.Duration
+	// ReusedConn reports whether the request was sent over a previously used connection.
+	ReusedConn bool
+}
+
+// WithTrace calls fn with the connection-level timings of each attempt of the call, once its response
+// headers are received or it failed, helping to attribute slow calls to the network or to the server.
+func WithTrace(fn func(Trace)) CallOption {
+	return func(o *callOptions) {
+		o.trace = fn
+	}
+}
+
+// traceRecorder gathers a [Trace] from the hooks of an [httptrace.ClientTrace].
+type traceRecorder struct {
+	// mu guards the fields below as connections to several addresses may be dialed concurrently, and
+	// dials may outlive the attempt on background goroutines.
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	trace        Trace
+}
+
+func newTraceRecorder() *traceRecorder {
+	return &traceRecorder{start: time.Now()}
+}
+
+func (r *traceRecorder) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.trace.ReusedConn = info.Reused
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.trace.DNSLookup = time.Since(r.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.connectStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.trace.Connect = time.Since(r.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.trace.TLSHandshake = time.Since(r.tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.trace.TimeToFirstByte = time.Since(r.start)
+		},
+	}
+}
+
+// done returns the trace of the attempt, ending it.
+func (r *traceRecorder) done() Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trace.Total = time.Since(r.start)
+	return r.trace
+}
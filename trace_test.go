@@ -0,0 +1,34 @@
+package wallet
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"testing"
+)
+
+func TestTraceRecorderConcurrentHooks(t *testing.T) {
+	r := newTraceRecorder()
+	trace := r.clientTrace()
+	var wg sync.WaitGroup
+	// dials may outlive the attempt, the hooks run concurrently with done, see go test -race.
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			trace.DNSStart(httptrace.DNSStartInfo{})
+			trace.DNSDone(httptrace.DNSDoneInfo{})
+			trace.ConnectStart("tcp", "127.0.0.1:443")
+			trace.ConnectDone("tcp", "127.0.0.1:443", nil)
+			trace.TLSHandshakeStart()
+			trace.TLSHandshakeDone(tls.ConnectionState{}, nil)
+			trace.GotConn(httptrace.GotConnInfo{Reused: true})
+			trace.GotFirstResponseByte()
+		}()
+	}
+	_ = r.done()
+	wg.Wait()
+	if got := r.done(); !got.ReusedConn || got.TimeToFirstByte <= 0 {
+		t.Errorf("got trace %+v, want every hook recorded", got)
+	}
+}
@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// WaitOptions configures [Client.WaitForRequest].
+type WaitOptions struct {
+	// Interval specifies the delay before polling the request again, doubled after each poll up to MaxInterval.
+	//
+	// Optional, defaulted to 2 seconds.
+	Interval time.Duration
+
+	// MaxInterval specifies the maximum delay between two polls.
+	//
+	// Optional, defaulted to 30 seconds.
+	MaxInterval time.Duration
+
+	// TerminalStatuses specifies the statuses at which the request stops being polled, see RequestStatus constants.
+	//
+	// Optional, defaulted to "completed", "cancelled" and "rejected".
+	TerminalStatuses []string
+}
+
+// WaitForRequest polls the request requestID of the account accountID with [Client.ListClientAccountRequests],
+// backing off between polls, until it reaches a terminal status, for instance, after [Client.CreateInvestmentRequest]
+// until the request is settled. It returns the request in its terminal status.
+//
+// When ctx is done first, it returns the request as last polled, if any, along with the error of ctx.
+//
+// Errors are the ones of [Client.ListClientAccountRequests], and [ErrMissingResource] when the request does not exist.
+func (c *Client) WaitForRequest(ctx context.Context, accountID string, requestID string, opts WaitOptions, callOpts ...CallOption) (*ClientAccountRequest, error) {
+	if requestID == "" {
+		return nil, Error{Code: ErrMissingParameter, Message: "wallet: request ID is required."}
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = 30 * time.Second
+	}
+	terminal := opts.TerminalStatuses
+	if len(terminal) == 0 {
+		terminal = []string{RequestStatusCompleted, RequestStatusCancelled, RequestStatusRejected}
+	}
+
+	var request *ClientAccountRequest
+	for {
+		output, err := c.ListClientAccountRequests(ctx, &ListClientAccountRequestsInput{
+			AccountID: accountID,
+			RequestID: &requestID,
+		}, callOpts...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return request, ctx.Err()
+			}
+			return request, err
+		}
+		i := slices.IndexFunc(output.Requests, func(r ClientAccountRequest) bool { return r.ID == requestID })
+		if i < 0 {
+			return nil, Error{Code: ErrMissingResource, Message: "wallet: request " + requestID + " not found."}
+		}
+		request = &output.Requests[i]
+		if slices.Contains(terminal, request.Status) {
+			return request, nil
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return request, err
+		}
+		interval = min(2*interval, maxInterval)
+	}
+}
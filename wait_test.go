@@ -0,0 +1,71 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newWaitClient returns a client whose request req_1 of account acc_1 has the statuses in turn, the last one
+// being kept, and a pointer to the number of polls.
+func newWaitClient(t *testing.T, statuses ...string) (*Client, *int) {
+	t.Helper()
+	polls := 0
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		var input ListClientAccountRequestsInput
+		if err := json.Unmarshal(decodeTestRequest(t, req).Payload, &input); err != nil {
+			t.Error(err)
+		}
+		if input.AccountID != "acc_1" || input.RequestID == nil || *input.RequestID != "req_1" {
+			return jsonResponse(http.StatusOK, map[string]any{"requests": []map[string]any{}}), nil
+		}
+		status := statuses[min(polls, len(statuses)-1)]
+		polls++
+		return jsonResponse(http.StatusOK, map[string]any{
+			"requests": []map[string]any{{"id": "req_1", "status": status, "amount": 1000}},
+		}), nil
+	})
+	return c, &polls
+}
+
+func TestWaitForRequest(t *testing.T) {
+	c, polls := newWaitClient(t, RequestStatusPending, RequestStatusProcessing, RequestStatusCompleted)
+	request, err := c.WaitForRequest(context.Background(), "acc_1", "req_1", WaitOptions{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Status != RequestStatusCompleted || request.Amount != 1000 || *polls != 3 {
+		t.Errorf("got request %+v after %d polls, want completed after 3", request, *polls)
+	}
+
+	// a custom terminal status stops earlier.
+	c, polls = newWaitClient(t, RequestStatusPending, RequestStatusProcessing, RequestStatusCompleted)
+	request, err = c.WaitForRequest(context.Background(), "acc_1", "req_1", WaitOptions{
+		Interval:         time.Millisecond,
+		TerminalStatuses: []string{RequestStatusProcessing},
+	})
+	if err != nil || request.Status != RequestStatusProcessing || *polls != 2 {
+		t.Errorf("got request %+v and error %v after %d polls, want processing after 2", request, err, *polls)
+	}
+
+	var werr Error
+	if _, err := c.WaitForRequest(context.Background(), "acc_1", "req_missing", WaitOptions{}); !errors.As(err, &werr) || werr.Code != ErrMissingResource {
+		t.Errorf("got error %v, want %s", err, ErrMissingResource)
+	}
+}
+
+func TestWaitForRequestTimeout(t *testing.T) {
+	c, polls := newWaitClient(t, RequestStatusPending)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	request, err := c.WaitForRequest(ctx, "acc_1", "req_1", WaitOptions{Interval: 5 * time.Millisecond, MaxInterval: 10 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if request == nil || request.Status != RequestStatusPending || *polls < 2 {
+		t.Errorf("got request %+v after %d polls, want the pending request as last polled", request, *polls)
+	}
+}
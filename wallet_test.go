@@ -1,10 +1,22 @@
 package wallet
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -120,3 +132,1852 @@ func TestSignRequestEC(t *testing.T) {
 	}
 	fmt.Println(jwtToken)
 }
+
+func TestCreateClientBankAccount(t *testing.T) {
+	var names []string
+	c := newTestClient(t, &Options{ValidateReferenceData: true}, func(req *http.Request) (*http.Response, error) {
+		r := decodeTestRequest(t, req)
+		names = append(names, r.Name)
+		switch r.Name {
+		case "list_banks":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"banks": []map[string]any{{"name": "Maybank", "bic": "MBBEMYKL"}},
+			}), nil
+		case "create_client_bank_account":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"bankAccountId": "ba_1",
+				"status":        "pending",
+			}), nil
+		}
+		t.Fatalf("unexpected request %q", r.Name)
+		return nil, nil
+	})
+
+	output, err := c.CreateClientBankAccount(context.Background(), &CreateClientBankAccountInput{
+		BankAccount: &BankAccount{BankBic: "MBBEMYKL", AccountNumber: "1234567890", AccountName: "John Doe"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.BankAccountID != "ba_1" || output.Status != "pending" {
+		t.Errorf("got %+v", output)
+	}
+
+	_, err = c.CreateClientBankAccount(context.Background(), &CreateClientBankAccountInput{
+		BankAccount: &BankAccount{BankBic: "UNKNOWN", AccountNumber: "1234567890", AccountName: "John Doe"},
+	})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Fatalf("got %v, want %s", err, ErrInvalidParameter)
+	}
+
+	// banks are fetched once and the unknown bank never reaches the server
+	want := []string{"list_banks", "create_client_bank_account"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("got requests %v, want %v", names, want)
+	}
+}
+
+func TestWriteClientAccountStatementCSV(t *testing.T) {
+	content := "date,type,amount\n2025-01-02,investment,1000.00\n2025-02-03,redemption,250.00\n"
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		if got := req.Header.Get("Accept"); got != "text/csv" {
+			t.Errorf("got Accept %q, want text/csv", got)
+		}
+		r := decodeTestRequest(t, req)
+		if !bytes.Contains(r.Payload, []byte(`"format":"csv"`)) {
+			t.Errorf("format not forwarded: %s", r.Payload)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/csv; charset=utf-8"}},
+			Body:       io.NopCloser(bytes.NewBufferString(content)),
+		}, nil
+	})
+
+	var buf bytes.Buffer
+	n, err := c.WriteClientAccountStatementCSV(context.Background(), &GetClientAccountStatementInput{
+		AccountID: "acc_1",
+		FromDate:  "2025-01-01",
+		ToDate:    "2025-12-31",
+	}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != content || n != int64(len(content)) {
+		t.Errorf("got %d bytes %q, want %q", n, buf.String(), content)
+	}
+}
+
+func TestInviteCoHolder(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		r := decodeTestRequest(t, req)
+		switch r.Name {
+		case "invite_co_holder":
+			var payload InviteCoHolderInput
+			if err := json.Unmarshal(r.Payload, &payload); err != nil {
+				t.Fatal(err)
+			}
+			return jsonResponse(http.StatusOK, map[string]any{
+				"invitation": map[string]any{
+					"id":            "inv_1",
+					"accountId":     payload.AccountID,
+					"coHolderEmail": payload.CoHolderEmail,
+					"status":        JointInvitationStatusPending,
+					"expiresAt":     "2025-01-09T00:00:00Z",
+				},
+			}), nil
+		case "get_joint_invitation_status":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"invitation": map[string]any{
+					"id":          "inv_1",
+					"status":      JointInvitationStatusAccepted,
+					"respondedAt": "2025-01-03T00:00:00Z",
+				},
+			}), nil
+		}
+		t.Fatalf("unexpected request %q", r.Name)
+		return nil, nil
+	})
+
+	invited, err := c.InviteCoHolder(context.Background(), &InviteCoHolderInput{
+		AccountID:     "acc_1",
+		CoHolderEmail: "jane@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv := invited.Invitation; inv.ID != "inv_1" || inv.Status != JointInvitationStatusPending || inv.CoHolderEmail != "jane@example.com" {
+		t.Errorf("got %+v", inv)
+	}
+
+	status, err := c.GetJointInvitationStatus(context.Background(), &GetJointInvitationStatusInput{InvitationID: invited.Invitation.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv := status.Invitation; inv.Status != JointInvitationStatusAccepted || inv.RespondedAt == nil {
+		t.Errorf("got %+v", inv)
+	}
+}
+
+func TestInviteCoHolderInvalidEmail(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		t.Fatal("request must not be sent")
+		return nil, nil
+	})
+	for _, email := range []string{"jane", "jane@", "Jane <jane@example.com>"} {
+		_, err := c.InviteCoHolder(context.Background(), &InviteCoHolderInput{AccountID: "acc_1", CoHolderEmail: email})
+		var werr Error
+		if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+			t.Errorf("%q: got %v, want %s", email, err, ErrInvalidParameter)
+		}
+	}
+}
+
+func TestListClientAccountsLabelFallback(t *testing.T) {
+	c := newTestClient(t, &Options{Language: "ms", FallbackLanguage: "en"}, func(req *http.Request) (*http.Response, error) {
+		switch req.Header.Get("Accept-Language") {
+		case "ms":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"accounts": []map[string]any{
+					{"id": "acc_1", "experienceLabel": "Pengurusan Dana", "riskLabel": ""},
+					{"id": "acc_2", "experienceLabel": "", "riskLabel": ""},
+				},
+			}), nil
+		case "en":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"accounts": []map[string]any{
+					{"id": "acc_1", "experienceLabel": "Fund Management", "riskLabel": "High"},
+					{"id": "acc_2", "experienceLabel": "DIM", "riskLabel": "Moderate", "riskDescription": "Balanced"},
+				},
+			}), nil
+		}
+		t.Fatalf("unexpected Accept-Language %q", req.Header.Get("Accept-Language"))
+		return nil, nil
+	})
+
+	output, err := c.ListClientAccounts(context.Background(), &ListClientAccountsInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := output.Accounts[0], output.Accounts[1]
+	// labels available in the primary language are kept
+	if first.ExperienceLabel != "Pengurusan Dana" || first.RiskLabel != "High" {
+		t.Errorf("got %+v", first)
+	}
+	if second.ExperienceLabel != "DIM" || second.RiskLabel != "Moderate" || second.RiskDescription != "Balanced" {
+		t.Errorf("got %+v", second)
+	}
+}
+
+func TestListClientAccountsNullBody(t *testing.T) {
+	var primaryBody any
+	c := newTestClient(t, &Options{Language: "ms", FallbackLanguage: "en"}, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Accept-Language") == "en" {
+			return jsonResponse(http.StatusOK, nil), nil
+		}
+		return jsonResponse(http.StatusOK, primaryBody), nil
+	})
+
+	// a null body is a nil output.
+	if output, err := c.ListClientAccounts(context.Background(), &ListClientAccountsInput{}); err != nil || output != nil {
+		t.Errorf("got output %v and error %v, want neither", output, err)
+	}
+
+	// a null body in the fallback language leaves the labels blank.
+	primaryBody = map[string]any{"accounts": []map[string]any{{"id": "acc_1"}}}
+	output, err := c.ListClientAccounts(context.Background(), &ListClientAccountsInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Accounts) != 1 || output.Accounts[0].ExperienceLabel != "" {
+		t.Errorf("got accounts %+v, want the account with blank labels", output.Accounts)
+	}
+}
+
+func TestUpdateClientProfilePartial(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		r := decodeTestRequest(t, req)
+		var payload map[string]any
+		if err := json.Unmarshal(r.Payload, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if len(payload) != 1 || payload["msisdn"] != "+60123456789" {
+			t.Errorf("got payload %s, want only msisdn", r.Payload)
+		}
+		return jsonResponse(http.StatusOK, map[string]any{
+			"profile": map[string]any{
+				"name":   "John Doe",
+				"msisdn": "+60123456789",
+				"email":  "john@example.com",
+			},
+		}), nil
+	})
+
+	msisdn := "+60123456789"
+	output, err := c.UpdateClientProfile(context.Background(), &UpdateClientProfileInput{Msisdn: &msisdn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := output.Profile; *p.Msisdn != msisdn || *p.Email != "john@example.com" {
+		t.Errorf("got %+v", p)
+	}
+}
+
+func TestGetClientAccountRequestConfirmationWriteTo(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n%confirmation\n")
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/confirmations/req_2.pdf" {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(pdf))}, nil
+		}
+		r := decodeTestRequest(t, req)
+		var input GetClientAccountRequestConfirmationInput
+		if err := json.Unmarshal(r.Payload, &input); err != nil {
+			t.Fatal(err)
+		}
+		if input.RequestID == "req_1" {
+			// bytes are base64 encoded on the wire
+			return jsonResponse(http.StatusOK, map[string]any{"format": "pdf", "filename": "req_1.pdf", "bytes": pdf}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"format": "pdf", "url": "https://media.halogen.my/confirmations/req_2.pdf"}), nil
+	})
+
+	for _, requestID := range []string{"req_1", "req_2"} {
+		output, err := c.GetClientAccountRequestConfirmation(context.Background(), &GetClientAccountRequestConfirmationInput{
+			AccountID: "acc_1",
+			RequestID: requestID,
+			Format:    "pdf",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		n, err := output.WriteTo(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), pdf) || n != int64(len(pdf)) {
+			t.Errorf("%s: got %d bytes %q, want %q", requestID, n, buf.Bytes(), pdf)
+		}
+	}
+}
+
+func TestGetClientAccountRequestConfirmationDownload(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n%confirmation\n")
+	middlewareCalls := 0
+	c := newTestClient(t, &Options{
+		Middlewares: []func(http.RoundTripper) http.RoundTripper{func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				middlewareCalls++
+				return next.RoundTrip(req)
+			})
+		}},
+	}, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "media.halogen.my" {
+			if err := req.Context().Err(); err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(pdf))}, nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"format": "pdf", "url": "https://media.halogen.my/confirmations/req_1.pdf"}), nil
+	})
+	output, err := c.GetClientAccountRequestConfirmation(context.Background(), &GetClientAccountRequestConfirmationInput{AccountID: "acc_1", RequestID: "req_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := output.Download(context.Background(), &buf); err != nil || !bytes.Equal(buf.Bytes(), pdf) {
+		t.Errorf("got %q and error %v, want %q", buf.Bytes(), err, pdf)
+	}
+	// the document is downloaded without the middlewares of the API.
+	if middlewareCalls != 1 {
+		t.Errorf("got %d requests through the middlewares, want 1", middlewareCalls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := output.Download(ctx, io.Discard); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestGetGoalProjection(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		r := decodeTestRequest(t, req)
+		var input GetGoalProjectionInput
+		if err := json.Unmarshal(r.Payload, &input); err != nil {
+			t.Fatal(err)
+		}
+		if input.TargetAmount <= 100000 {
+			return jsonResponse(http.StatusOK, map[string]any{
+				"asset":                 "MYR",
+				"requiredContribution":  1523.4,
+				"contributionFrequency": "monthly",
+				"projectedValue":        input.TargetAmount,
+				"assumedAnnualReturn":   6,
+				"reachable":             true,
+			}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{
+			"asset":                 "MYR",
+			"requiredContribution":  250000,
+			"contributionFrequency": "monthly",
+			"projectedValue":        400000,
+			"assumedAnnualReturn":   6,
+			"reachable":             false,
+			"shortfall":             input.TargetAmount - 400000,
+		}), nil
+	})
+
+	reachable, err := c.GetGoalProjection(context.Background(), &GetGoalProjectionInput{AccountID: "acc_1", TargetAmount: 100000, ByDate: "2030-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reachable.Reachable || reachable.Shortfall != 0 || reachable.RequiredContribution != 1523.4 {
+		t.Errorf("got %+v", reachable)
+	}
+
+	unreachable, err := c.GetGoalProjection(context.Background(), &GetGoalProjectionInput{AccountID: "acc_1", TargetAmount: 10000000, ByDate: "2026-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unreachable.Reachable || unreachable.Shortfall != 9600000 {
+		t.Errorf("got %+v", unreachable)
+	}
+}
+
+func TestListPaymentMethods(t *testing.T) {
+	var payloads []string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		r := decodeTestRequest(t, req)
+		payloads = append(payloads, string(r.Payload))
+		return jsonResponse(http.StatusOK, map[string]any{
+			"duitnow":      true,
+			"bankTransfer": false,
+			"paymentMethods": []map[string]any{
+				{"id": "pm_1", "label": "DuitNow", "type": "duitnow", "asset": "MYR", "minimumAmount": 100, "maximumAmount": 50000, "experiences": []string{"fundmanagement", "dim"}},
+				{"id": "pm_2", "label": "FPX", "type": "fpx", "asset": "MYR", "minimumAmount": 10, "experiences": []string{"dim"}},
+			},
+		}), nil
+	})
+
+	output, err := c.ListPaymentMethods(context.Background(), &ListPaymentMethodsInput{AccountID: "acc_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.PaymentMethods) != 2 {
+		t.Fatalf("got %d payment methods, want 2", len(output.PaymentMethods))
+	}
+	duitnow, fpx := output.PaymentMethods[0], output.PaymentMethods[1]
+	if duitnow.Type != PaymentMethodTypeDuitnow || duitnow.MinimumAmount != 100 || duitnow.MaximumAmount != 50000 {
+		t.Errorf("got %+v", duitnow)
+	}
+	if !duitnow.SupportsExperience(AccountExperienceFundManagement) || fpx.SupportsExperience(AccountExperienceFundManagement) {
+		t.Errorf("unexpected experiences %v %v", duitnow.Experiences, fpx.Experiences)
+	}
+
+	if _, err := c.ListPaymentMethods(context.Background(), &ListPaymentMethodsInput{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`{"accountId":"acc_1"}`, `{}`}
+	if fmt.Sprint(payloads) != fmt.Sprint(want) {
+		t.Errorf("got payloads %v, want %v", payloads, want)
+	}
+}
+
+func TestGetVoucher(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, map[string]any{
+			"valid": true,
+			"code":  "CASH10",
+			"voucher": map[string]any{
+				"code":         "CASH10",
+				"type":         "cashback",
+				"value":        10,
+				"expiresAt":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+				"minimumSpend": 1000,
+				"fundIds":      []string{"fund_1"},
+				"experiences":  []string{"dim"},
+			},
+		}), nil
+	})
+
+	code := "CASH10"
+	output, err := c.GetVoucher(context.Background(), &GetVoucherInput{VoucherCode: &code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := output.Voucher
+	if v == nil || v.Type != VoucherTypeCashback || v.Value != 10 || v.MinimumSpend != 1000 {
+		t.Fatalf("got %+v", v)
+	}
+	if !v.AppliesTo("fund_1", 1000) {
+		t.Errorf("voucher should apply to fund_1 for 1000")
+	}
+}
+
+func TestVoucherAppliesToOutOfScope(t *testing.T) {
+	expired := WalletTime{time.Now().Add(-time.Hour)}
+	v := &Voucher{MinimumSpend: 1000, FundIDs: []string{"fund_1"}}
+	tests := []struct {
+		name    string
+		voucher *Voucher
+		fundID  string
+		amount  float64
+	}{
+		{"other fund", v, "fund_2", 5000},
+		{"below minimum spend", v, "fund_1", 999},
+		{"expired", &Voucher{ExpiresAt: &expired}, "fund_1", 5000},
+		{"nil voucher", nil, "fund_1", 5000},
+	}
+	for _, tt := range tests {
+		if tt.voucher.AppliesTo(tt.fundID, tt.amount) {
+			t.Errorf("%s: voucher should not apply", tt.name)
+		}
+	}
+}
+
+func TestUpdateAccountName(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{
+			"account": map[string]any{"id": "acc_1", "name": "Rainy Day Fund", "canUpdateAccountName": true},
+		}), nil
+	})
+
+	output, err := c.UpdateAccountName(context.Background(), &UpdateAccountNameInput{AccountID: "acc_1", AccountName: "Rainy Day Fund"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Account == nil || output.Account.Name != "Rainy Day Fund" {
+		t.Errorf("got account %+v", output.Account)
+	}
+	if want := `{"accountId":"acc_1","accountName":"Rainy Day Fund"}`; payload != want {
+		t.Errorf("got payload %s, want %s", payload, want)
+	}
+}
+
+func TestUpdateAccountNameInvalid(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		t.Fatal("request should not be sent")
+		return nil, nil
+	})
+
+	for _, name := range []string{
+		strings.Repeat("a", MaxAccountNameLength+1),
+		"Savings\n",
+		"Holiday 🏖️",
+	} {
+		_, err := c.UpdateAccountName(context.Background(), &UpdateAccountNameInput{AccountID: "acc_1", AccountName: name})
+		var werr Error
+		if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+			t.Errorf("name %q: got %v, want %s", name, err, ErrInvalidParameter)
+		}
+	}
+}
+
+func TestCreateSuitabilityAssessment(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{
+			"suitabilityAssessmentId": "sa_1",
+			"totalScore":              18,
+			"riskTolerance":           "moderate",
+		}), nil
+	})
+
+	output, err := c.CreateSuitabilityAssessment(context.Background(), &CreateSuitabilityAssessmentInput{
+		Answers: []SuitabilityAnswer{
+			{QuestionID: SuitabilityQuestionRiskTolerance, AnswerID: "b"},
+			{QuestionID: SuitabilityQuestionInvestmentHorizon, AnswerID: "c"},
+			{QuestionID: SuitabilityQuestionInvestmentExperience, AnswerID: "a"},
+			{QuestionID: SuitabilityQuestionInvestmentKnowledge, AnswerID: "d"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.SuitabilityAssessmentID != "sa_1" || output.RiskTolerance != "moderate" || output.TotalScore != 18 {
+		t.Errorf("got %+v", output)
+	}
+	if !strings.Contains(payload, `{"questionId":"risk_tolerance","answerId":"b"}`) {
+		t.Errorf("got payload %s", payload)
+	}
+}
+
+func TestCreateSuitabilityAssessmentMissingAnswer(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		t.Fatal("request should not be sent")
+		return nil, nil
+	})
+
+	_, err := c.CreateSuitabilityAssessment(context.Background(), &CreateSuitabilityAssessmentInput{
+		Answers: []SuitabilityAnswer{
+			{QuestionID: SuitabilityQuestionRiskTolerance, AnswerID: "b"},
+			{QuestionID: SuitabilityQuestionInvestmentHorizon, AnswerID: "c"},
+			{QuestionID: SuitabilityQuestionInvestmentExperience, AnswerID: "a"},
+		},
+	})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrMissingParameter || !strings.Contains(werr.Message, SuitabilityQuestionInvestmentKnowledge) {
+		t.Fatalf("got %v, want %s for %s", err, ErrMissingParameter, SuitabilityQuestionInvestmentKnowledge)
+	}
+}
+
+func TestListClientAccountRequestsStatusFilter(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{
+			"requests": []map[string]any{
+				{"id": "req_1", "type": "investment", "status": "pending", "amount": 1000, "fundId": "fund_1", "createdAt": "2025-01-02T03:04:05Z"},
+			},
+			"nextCursor": "cur_2",
+		}), nil
+	})
+
+	status, from, to := RequestStatusPending, "2025-01-01", "2025-01-31"
+	output, err := c.ListClientAccountRequests(context.Background(), &ListClientAccountRequestsInput{
+		AccountID: "acc_1",
+		Statuses:  []*string{&status},
+		FromDate:  &from,
+		ToDate:    &to,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"accountId":"acc_1","fromDate":"2025-01-01","toDate":"2025-01-31","statuses":["pending"]}`; payload != want {
+		t.Errorf("got payload %s, want %s", payload, want)
+	}
+	if len(output.Requests) != 1 || output.Requests[0].Status != RequestStatusPending || output.Requests[0].Type != RequestTypeInvestment {
+		t.Errorf("got requests %+v", output.Requests)
+	}
+	if output.NextCursor != "cur_2" {
+		t.Errorf("got next cursor %q, want cur_2", output.NextCursor)
+	}
+}
+
+func TestListClientAccountRequestsInvalidDateRange(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		t.Fatal("request should not be sent")
+		return nil, nil
+	})
+
+	from, to := "2025-02-01", "2025-01-31"
+	_, err := c.ListClientAccountRequests(context.Background(), &ListClientAccountRequestsInput{AccountID: "acc_1", FromDate: &from, ToDate: &to})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidDateRange {
+		t.Fatalf("got %v, want %s", err, ErrInvalidDateRange)
+	}
+}
+
+func TestGetClientAccountRequestPolicy(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{
+			"dealingPolicy": map[string]any{
+				"cutoffTime":        "15:00",
+				"settlementDays":    2,
+				"minimumAmount":     100,
+				"allowedOperations": []string{"investment", "redemption"},
+				"holidays":          []string{"2025-01-06"},
+			},
+		}), nil
+	})
+
+	output, err := c.GetClientAccountRequestPolicy(context.Background(), &GetClientAccountRequestPolicyInput{AccountID: "acc_1", FundID: "fund_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"accountId":"acc_1","requestId":"","fundId":"fund_1"}`; payload != want {
+		t.Errorf("got payload %s, want %s", payload, want)
+	}
+	p := output.DealingPolicy
+	if p == nil || p.CutoffTime != "15:00" || p.SettlementDays != 2 || len(p.AllowedOperations) != 2 {
+		t.Fatalf("got policy %+v", p)
+	}
+}
+
+func TestRequestPolicyNextCutoff(t *testing.T) {
+	myt := time.FixedZone("MYT", 8*60*60)
+	friday := func(hour int) time.Time { return time.Date(2025, 1, 3, hour, 0, 0, 0, myt) }
+	tests := []struct {
+		name   string
+		policy RequestPolicy
+		now    time.Time
+		want   time.Time
+	}{
+		{"before cutoff", RequestPolicy{CutoffTime: "15:00"}, friday(10), friday(15)},
+		{"friday afternoon rolls to monday", RequestPolicy{CutoffTime: "15:00"}, friday(16), time.Date(2025, 1, 6, 15, 0, 0, 0, myt)},
+		{"monday holiday rolls to tuesday", RequestPolicy{CutoffTime: "15:00", Holidays: []string{"2025-01-06"}}, friday(16), time.Date(2025, 1, 7, 15, 0, 0, 0, myt)},
+		{"invalid cutoff", RequestPolicy{CutoffTime: "3pm"}, friday(10), time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.NextCutoff(tt.now); !got.Equal(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// statementReader generates a detailed statement of n transactions without holding it in memory.
+type statementReader struct {
+	n, i int
+	buf  bytes.Buffer
+}
+
+func (r *statementReader) Read(p []byte) (int, error) {
+	for r.buf.Len() < len(p) && r.i <= r.n {
+		switch {
+		case r.i == 0:
+			r.buf.WriteString(`{"fromDate":"2025-01-01","format":"pdf","transactions":[`)
+		case r.i < r.n:
+			fmt.Fprintf(&r.buf, `{"id":"tx_%d","type":"investment","date":"2025-01-02T03:04:05Z","fundId":"fund_1","amount":100.5,"units":10},`, r.i)
+		default:
+			fmt.Fprintf(&r.buf, `{"id":"tx_%d","type":"investment","date":"2025-01-02T03:04:05Z","fundId":"fund_1","amount":100.5,"units":10}],"filename":"statement.pdf"}`, r.i)
+		}
+		r.i++
+	}
+	if r.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return r.buf.Read(p)
+}
+
+func TestStreamClientAccountStatement(t *testing.T) {
+	const n = 200000
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(&statementReader{n: n}),
+		}, nil
+	})
+
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	baseline, peak := m.HeapAlloc, m.HeapAlloc
+	count := 0
+	err := c.StreamClientAccountStatement(context.Background(), &GetClientAccountStatementInput{AccountID: "acc_1"}, func(tx StatementTransaction) error {
+		if tx.ID != fmt.Sprintf("tx_%d", count+1) || tx.Amount != 100.5 || tx.Date.IsZero() {
+			t.Fatalf("unexpected transaction %+v", tx)
+		}
+		count++
+		if count%50000 == 0 {
+			runtime.GC()
+			runtime.ReadMemStats(&m)
+			peak = max(peak, m.HeapAlloc)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != n {
+		t.Errorf("got %d transactions, want %d", count, n)
+	}
+	if !strings.Contains(payload, `"detailed":true`) {
+		t.Errorf("got payload %s", payload)
+	}
+	// the body is about 20MB, the live heap must not grow with it.
+	if peak > baseline+4<<20 {
+		t.Errorf("heap grew from %d to %d bytes while streaming", baseline, peak)
+	}
+}
+
+func TestStreamClientAccountStatementStopsOnError(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(&statementReader{n: 10}),
+		}, nil
+	})
+
+	errStop := errors.New("stop")
+	count := 0
+	err := c.StreamClientAccountStatement(context.Background(), &GetClientAccountStatementInput{AccountID: "acc_1"}, func(tx StatementTransaction) error {
+		count++
+		if count == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || count != 3 {
+		t.Errorf("got err %v after %d transactions, want %v after 3", err, count, errStop)
+	}
+}
+
+func TestCreateRequestCancellation(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{"status": "cancelled"}), nil
+	})
+
+	output, err := c.CreateRequestCancellation(context.Background(), &CreateRequestCancellationInput{
+		AccountID:  "acc_1",
+		RequestID:  "req_1",
+		ReasonCode: CancellationReasonIncorrectAmount,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Status != RequestStatusCancelled {
+		t.Errorf("got status %q, want %q", output.Status, RequestStatusCancelled)
+	}
+	if want := `{"accountId":"acc_1","requestId":"req_1","reasonCode":"incorrect_amount"}`; payload != want {
+		t.Errorf("got payload %s, want %s", payload, want)
+	}
+
+	_, err = c.CreateRequestCancellation(context.Background(), &CreateRequestCancellationInput{AccountID: "acc_1"})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrMissingParameter {
+		t.Errorf("got %v, want %s", err, ErrMissingParameter)
+	}
+}
+
+func TestCreateRequestCancellationPastCutoff(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusBadRequest, map[string]any{
+			"code":    "ErrRequestCannotBeCancelled",
+			"message": "request is past its cutoff",
+		}), nil
+	})
+
+	_, err := c.CreateRequestCancellation(context.Background(), &CreateRequestCancellationInput{AccountID: "acc_1", RequestID: "req_1"})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrRequestNotCancellable {
+		t.Fatalf("got %v, want %s", err, ErrRequestNotCancellable)
+	}
+}
+
+func TestListClientSuitabilityAssessmentsLatestValid(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, map[string]any{
+			"assessments": []map[string]any{
+				{"id": "sa_1", "riskTolerance": "conservative", "createdAt": "2023-01-01T00:00:00Z", "expired": true},
+				{"id": "sa_2", "riskTolerance": "moderate", "createdAt": "2024-06-01T00:00:00Z", "expiresAt": time.Now().Add(24 * time.Hour).Format(time.RFC3339)},
+				{"id": "sa_3", "riskTolerance": "aggressive", "createdAt": "2024-01-01T00:00:00Z"},
+				{"id": "sa_4", "riskTolerance": "aggressive", "createdAt": "2025-01-01T00:00:00Z", "expiresAt": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+			},
+		}), nil
+	})
+
+	output, err := c.ListClientSuitabilityAssessments(context.Background(), &ListClientSuitabilityAssessmentsInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !output.Assessments[0].Expired || output.Assessments[1].ExpiresAt == nil {
+		t.Errorf("expiry flags not decoded: %+v", output.Assessments)
+	}
+	if latest := output.LatestValidAssessment(); latest == nil || latest.ID != "sa_2" {
+		t.Errorf("got latest valid assessment %+v, want sa_2", latest)
+	}
+
+	output.Assessments = output.Assessments[:1]
+	if latest := output.LatestValidAssessment(); latest != nil {
+		t.Errorf("got latest valid assessment %+v, want nil", latest)
+	}
+}
+
+func TestGetClientReferral(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{
+			"referralCode":         "ALICE42",
+			"referredClientsCount": 2,
+			"shareUrl":             "https://wallet.halogen.my/r/ALICE42?campaign=raya2025",
+			"invitedCount":         5,
+			"rewardsAsset":         "MYR",
+			"rewardsEarned":        40,
+		}), nil
+	})
+
+	output, err := c.GetClientReferral(context.Background(), &GetClientReferralInput{Campaign: "raya2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"campaign":"raya2025"}`; payload != want {
+		t.Errorf("got payload %s, want %s", payload, want)
+	}
+	if output.ReferralCode != "ALICE42" || output.InvitedCount != 5 || output.ReferredClientsCount != 2 || output.RewardsEarned != 40 {
+		t.Errorf("got %+v", output)
+	}
+	if !strings.Contains(output.ShareUrl, "campaign=raya2025") {
+		t.Errorf("got share URL %q", output.ShareUrl)
+	}
+}
+
+func TestCreateDuitnowPayment(t *testing.T) {
+	qr := testEMVCoQR()
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		r := decodeTestRequest(t, req)
+		switch r.Name {
+		case "create_duitnow_payment":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"payment": map[string]any{"endToEndId": "20250102MBBEMYKL010ORB12345678", "asset": "MYR", "amount": 10, "reference": "INV-1", "qrPayload": qr},
+			}), nil
+		case "get_request_by_duitnow_end_to_end_id":
+			var payload GetRequestByDuitNowEndToEndIDInput
+			if err := json.Unmarshal(r.Payload, &payload); err != nil {
+				t.Fatal(err)
+			}
+			return jsonResponse(http.StatusOK, map[string]any{
+				"request": map[string]any{"id": "req_1", "amount": 10, "duitnowEndToEndId": payload.EndToEndID},
+			}), nil
+		}
+		t.Fatalf("unexpected request %s", r.Name)
+		return nil, nil
+	})
+
+	output, err := c.CreateDuitnowPayment(context.Background(), &CreateDuitnowPaymentInput{AccountID: "acc_1", Amount: 10, Reference: "INV-1", Target: "req_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment := output.Payment
+	if payment == nil || payment.QRPayload == "" {
+		t.Fatalf("got payment %+v without QR payload", payment)
+	}
+	if err := ValidateEMVCoQR(payment.QRPayload); err != nil {
+		t.Error(err)
+	}
+
+	lookup, err := c.GetRequestByDuitNowEndToEndID(context.Background(), &GetRequestByDuitNowEndToEndIDInput{EndToEndID: payment.EndToEndID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookup.Request == nil || lookup.Request.DuitnowEndToEndID != payment.EndToEndID {
+		t.Errorf("got request %+v, want end-to-end ID %s", lookup.Request, payment.EndToEndID)
+	}
+}
+
+func TestListInvestConsentsPending(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, map[string]any{
+			"consents": []map[string]any{
+				{"id": "con_1", "name": "fund_im", "documentUrl": "https://example.com/im.pdf", "version": "2", "accepted": true},
+				{"id": "con_2", "name": "high_risk", "documentUrl": "https://example.com/risk.pdf", "version": "1", "accepted": false},
+				{"id": "con_3", "name": "prospectus", "documentUrl": "https://example.com/prospectus.pdf", "version": "3"},
+			},
+		}), nil
+	})
+
+	output, err := c.ListInvestConsents(context.Background(), &ListInvestConsentsInput{AccountID: "acc_1", FundID: "fund_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := output.Consents[0]; c.ID != "con_1" || c.Version != "2" || c.DocumentUrl == "" || !c.Accepted {
+		t.Errorf("got consent %+v", c)
+	}
+	pending := output.PendingConsents()
+	if len(pending) != 2 || pending[0].ID != "con_2" || pending[1].ID != "con_3" {
+		t.Errorf("got pending consents %+v, want con_2 and con_3", pending)
+	}
+}
+
+func TestUpdateDisplayCurrency(t *testing.T) {
+	var names []string
+	c := newTestClient(t, &Options{ValidateReferenceData: true}, func(req *http.Request) (*http.Response, error) {
+		r := decodeTestRequest(t, req)
+		names = append(names, r.Name)
+		switch r.Name {
+		case "list_display_currencies":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"currencies": []map[string]any{{"id": "MYR", "label": "Malaysian Ringgit"}, {"id": "USD", "label": "US Dollar"}},
+			}), nil
+		case "update_display_currency":
+			return jsonResponse(http.StatusOK, map[string]any{"displayCurrency": "USD"}), nil
+		}
+		t.Fatalf("unexpected request %q", r.Name)
+		return nil, nil
+	})
+
+	output, err := c.UpdateDisplayCurrency(context.Background(), &UpdateDisplayCurrencyInput{DisplayCurrency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.DisplayCurrency != "USD" {
+		t.Errorf("got display currency %q, want USD", output.DisplayCurrency)
+	}
+
+	_, err = c.UpdateDisplayCurrency(context.Background(), &UpdateDisplayCurrencyInput{DisplayCurrency: "XYZ"})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Fatalf("got %v, want %s", err, ErrInvalidParameter)
+	}
+
+	// a nil input fails before validating the currency.
+	if _, err = c.UpdateDisplayCurrency(context.Background(), nil); !errors.As(err, &werr) || werr.Code != ErrMissingParameter {
+		t.Fatalf("got %v, want %s", err, ErrMissingParameter)
+	}
+
+	// currencies are fetched once and the unsupported currency never reaches the server
+	want := []string{"list_display_currencies", "update_display_currency"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("got requests %v, want %v", names, want)
+	}
+}
+
+func TestListDuitNowBanksOnlineOnly(t *testing.T) {
+	var payloads []string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payloads = append(payloads, string(decodeTestRequest(t, req).Payload))
+		// the server may mark a bank down after filtering, the response keeps an unavailable bank.
+		return jsonResponse(http.StatusOK, map[string]any{
+			"banks": []map[string]any{
+				{"code": "MBBEMYKL", "name": "Maybank", "onlineBankingAvailable": true},
+				{"code": "CIBBMYKL", "name": "CIMB", "onlineBankingAvailable": false},
+				{"code": "RHBBMYKL", "name": "RHB", "onlineBankingAvailable": true},
+			},
+		}), nil
+	})
+
+	all, err := c.ListDuitNowBanks(context.Background(), &ListDuitNowBanksInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Banks) != 3 || all.Banks[1].OnlineBankingAvailable {
+		t.Errorf("got banks %+v", all.Banks)
+	}
+
+	online, err := c.ListDuitNowBanks(context.Background(), &ListDuitNowBanksInput{OnlineOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(online.Banks) != 2 || online.Banks[0].Code != "MBBEMYKL" || online.Banks[1].Code != "RHBBMYKL" {
+		t.Errorf("got online banks %+v", online.Banks)
+	}
+	want := []string{`{}`, `{"onlineOnly":true}`}
+	if fmt.Sprint(payloads) != fmt.Sprint(want) {
+		t.Errorf("got payloads %v, want %v", payloads, want)
+	}
+}
+
+func TestListClientAccountMandateRequests(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{
+			"requests": []map[string]any{
+				{
+					"id":            "mr_1",
+					"status":        "pending",
+					"asset":         "MYR",
+					"amount":        1000000,
+					"parameters":    map[string]any{"strategy": "balanced", "riskProfile": "moderate", "managementFeePercentage": 1.5},
+					"effectiveFrom": "2025-02-01",
+				},
+			},
+		}), nil
+	})
+
+	output, err := c.ListClientAccountMandateRequests(context.Background(), &ListClientAccountMandateRequestsInput{
+		AccountID: "acc_1",
+		Statuses:  []string{RequestStatusPending},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"accountId":"acc_1","statuses":["pending"]}`; payload != want {
+		t.Errorf("got payload %s, want %s", payload, want)
+	}
+	if len(output.Requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(output.Requests))
+	}
+	r := output.Requests[0]
+	if r.ID != "mr_1" || r.Status != RequestStatusPending || r.EffectiveFrom != "2025-02-01" || r.Parameters == nil || r.Parameters.Strategy != "balanced" {
+		t.Errorf("got request %+v", r)
+	}
+}
+
+func TestListClientPromosEligible(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		today := time.Now().UTC().Format(time.DateOnly)
+		return jsonResponse(http.StatusOK, map[string]any{
+			"promos": []map[string]any{
+				{"code": "ANYFUND", "rewardType": "cashback", "minimumSpend": 500, "validFromDate": "2020-01-01", "validToDate": today},
+				{"code": "BIGSPEND", "rewardType": "fee_waiver", "minimumSpend": 10000, "fundIds": []string{"fund_1"}},
+				{"code": "OTHERFUND", "rewardType": "cashback", "fundIds": []string{"fund_2"}},
+				{"code": "ENDED", "rewardType": "cashback", "validToDate": "2020-01-31"},
+			},
+		}), nil
+	})
+
+	output, err := c.ListClientPromos(context.Background(), &ListClientPromosInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := output.Promos[1]; p.RewardType != VoucherTypeFeeWaiver || p.MinimumSpend != 10000 {
+		t.Errorf("got promo %+v", p)
+	}
+
+	codes := func(promos []Promo) string {
+		var s []string
+		for _, p := range promos {
+			s = append(s, p.Code)
+		}
+		return fmt.Sprint(s)
+	}
+	if got, want := codes(output.EligiblePromos("fund_1", 1000)), "[ANYFUND]"; got != want {
+		t.Errorf("below BIGSPEND minimum spend: got %s, want %s", got, want)
+	}
+	if got, want := codes(output.EligiblePromos("fund_1", 10000)), "[ANYFUND BIGSPEND]"; got != want {
+		t.Errorf("meeting BIGSPEND minimum spend: got %s, want %s", got, want)
+	}
+	if got, want := codes(output.EligiblePromos("fund_3", 100)), "[]"; got != want {
+		t.Errorf("below all minimum spends: got %s, want %s", got, want)
+	}
+}
+
+func TestGetClientAccountAllocationPerformance(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{
+			"allocations": []map[string]any{
+				{
+					"fundId": "fund_1", "weightPercentage": 60, "returnPercentage": 4.2,
+					"series": []map[string]any{{"date": "2025-01-31", "value": 6000}, {"date": "2025-02-28", "value": 6252}},
+				},
+				{
+					"fundId": "fund_2", "weightPercentage": 40, "returnPercentage": -1.1,
+					"series": []map[string]any{{"date": "2025-01-31", "value": 4000}, {"date": "2025-02-28", "value": 3956}},
+				},
+			},
+		}), nil
+	})
+
+	output, err := c.GetClientAccountAllocationPerformance(context.Background(), &GetClientAccountAllocationPerformanceInput{AccountID: "acc_1", Interval: IntervalMonthly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"accountId":"acc_1","interval":"monthly"}`; payload != want {
+		t.Errorf("got payload %s, want %s", payload, want)
+	}
+	if len(output.Allocations) != 2 {
+		t.Fatalf("got %d allocations, want 2", len(output.Allocations))
+	}
+	if a := output.Allocations[1]; a.FundID != "fund_2" || a.WeightPercentage != 40 || a.ReturnPercentage != -1.1 || len(a.Series) != 2 || a.Series[1].Value != 3956 {
+		t.Errorf("got allocation %+v", a)
+	}
+
+	_, err = c.GetClientAccountAllocationPerformance(context.Background(), &GetClientAccountAllocationPerformanceInput{AccountID: "acc_1", Interval: "hourly"})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got %v, want %s", err, ErrInvalidParameter)
+	}
+}
+
+func TestListClientAccountPerformanceBenchmark(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{
+			"performance": []map[string]any{{"date": "2025-01-31", "accountId": "acc_1", "value": 100}, {"date": "2025-02-28", "accountId": "acc_1", "value": 104}},
+			"benchmark":   []map[string]any{{"date": "2025-01-31", "value": 100}, {"date": "2025-02-28", "value": 102}},
+			"returns":     []map[string]any{{"accountId": "acc_1", "timeWeightedReturnPercentage": 4, "moneyWeightedReturnPercentage": 3.8}},
+		}), nil
+	})
+
+	output, err := c.ListClientAccountPerformance(context.Background(), &ListClientAccountPerformanceInput{
+		AccountIDs: []string{"acc_1"},
+		FromDate:   "2025-01-31",
+		ToDate:     "2025-02-28",
+		Benchmark:  "KLCI",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"accountIds":["acc_1"],"fromDate":"2025-01-31","toDate":"2025-02-28","benchmark":"KLCI"}`; payload != want {
+		t.Errorf("got payload %s, want %s", payload, want)
+	}
+	if len(output.Benchmark) != 2 || output.Benchmark[1].Value != 102 {
+		t.Errorf("got benchmark %+v", output.Benchmark)
+	}
+	if len(output.Returns) != 1 || output.Returns[0].TimeWeightedReturnPercentage == nil || *output.Returns[0].TimeWeightedReturnPercentage != 4 {
+		t.Errorf("got returns %+v", output.Returns)
+	}
+
+	_, err = c.ListClientAccountPerformance(context.Background(), &ListClientAccountPerformanceInput{FromDate: "2025-03-01", ToDate: "2025-02-28"})
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidDateRange {
+		t.Errorf("got %v, want %s", err, ErrInvalidDateRange)
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	garbagePEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("garbage")})
+
+	for _, tt := range []struct {
+		name     string
+		key      []byte
+		wantCode string
+		// wantLoaderCode is the code wanted when the key is returned by CredentialsLoaderFunc.
+		wantLoaderCode string
+	}{
+		{"rsa", rsaPEM, "", ""},
+		{"ec", testECPrivateKeyPEM(t), "", ""},
+		{"garbage", garbagePEM, ErrInvalidPrivateKey, ErrInvalidPrivateKey},
+		{"not pem", []byte("garbage"), ErrInvalidPrivateKey, ErrCredentialsNotSet},
+	} {
+		c := New()
+		c.SetCredentials(testKeyID, tt.key)
+		checkValidateCredentials(t, tt.name, c.ValidateCredentials(), tt.wantCode)
+
+		// the key is copied as the loader's key is cleaned from the memory after use.
+		c = New(&Options{CredentialsLoaderFunc: func() (string, []byte, error) {
+			return testKeyID, bytes.Clone(tt.key), nil
+		}})
+		checkValidateCredentials(t, tt.name+" loader", c.ValidateCredentials(), tt.wantLoaderCode)
+	}
+
+	// a key failing the validation of the loader is cleaned from the memory too.
+	for _, tt := range []struct {
+		name  string
+		keyID string
+		key   []byte
+	}{
+		{"not pem", testKeyID, []byte("garbage")},
+		{"empty key ID", "", testECPrivateKeyPEM(t)},
+	} {
+		c := New(&Options{CredentialsLoaderFunc: func() (string, []byte, error) {
+			return tt.keyID, tt.key, nil
+		}})
+		if err := c.ValidateCredentials(); err == nil {
+			t.Errorf("%s: got no error", tt.name)
+		}
+		if !bytes.Equal(tt.key, make([]byte, len(tt.key))) {
+			t.Errorf("%s: got the key left in memory", tt.name)
+		}
+	}
+}
+
+func checkValidateCredentials(t *testing.T, name string, err error, wantCode string) {
+	t.Helper()
+	if wantCode == "" {
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		return
+	}
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != wantCode {
+		t.Errorf("%s: got %v, want %s", name, err, wantCode)
+	}
+}
+
+func TestCommandInputsOmitZeroFields(t *testing.T) {
+	for _, input := range []any{
+		CreateInvestmentRequestInput{},
+		CreateRedemptionRequestInput{},
+		CreateSwitchRequestInput{},
+		CreateRequestCancellationInput{},
+		CreateSuitabilityAssessmentInput{},
+		CreateClientBankAccountInput{},
+		UpdateDisplayCurrencyInput{},
+		UpdateAccountNameInput{},
+		UpdateClientProfileInput{},
+		InviteCoHolderInput{},
+		CreateDuitnowPaymentInput{},
+	} {
+		b, err := json.Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "{}" {
+			t.Errorf("%T: got %s, want fields irrelevant to the request omitted", input, b)
+		}
+	}
+}
+
+func TestCreateInvestmentRequestFundManagementPayload(t *testing.T) {
+	var payload map[string]any
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		r := decodeTestRequest(t, req)
+		if err := json.Unmarshal(r.Payload, &payload); err != nil {
+			t.Fatal(err)
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"requestId": "req_1"}), nil
+	})
+	_, err := c.CreateInvestmentRequest(context.Background(), &CreateInvestmentRequestInput{
+		AccountID:         "acc_1",
+		FundID:            "fund_1",
+		FundClassSequence: 1,
+		Amount:            NewAmount(1000, "MYR"),
+		Consents:          map[string]bool{"fundIM": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"accountId", "amount", "consents", "fundClassSequence", "fundId"}
+	got := make([]string, 0, len(payload))
+	for key := range payload {
+		got = append(got, key)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got payload fields %v, want %v", got, want)
+	}
+}
+
+func TestValidateSwitchPlan(t *testing.T) {
+	valid := []CreateSwitchRequestInput{
+		{AccountID: "acc_1", SwitchFromFundID: "fund_a", SwitchFromFundClassSequence: 1, SwitchToFundID: "fund_c", SwitchToFundClassSequence: 1, RequestedAmount: 500},
+		{AccountID: "acc_1", SwitchFromFundID: "fund_b", SwitchFromFundClassSequence: 1, SwitchToFundID: "fund_c", SwitchToFundClassSequence: 1, Units: 120.5},
+	}
+	if err := ValidateSwitchPlan(valid); err != nil {
+		t.Errorf("got %v for a valid plan", err)
+	}
+
+	duplicate := append(valid, CreateSwitchRequestInput{
+		AccountID: "acc_1", SwitchFromFundID: "fund_a", SwitchFromFundClassSequence: 1, SwitchToFundID: "fund_d", SwitchToFundClassSequence: 1, RequestedAmount: 100,
+	})
+	err := ValidateSwitchPlan(duplicate)
+	var werr Error
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Fatalf("got %v, want %s", err, ErrInvalidParameter)
+	}
+	if !strings.Contains(werr.Message, "entry 2: fund fund_a class 1 is already switched from by entry 0") {
+		t.Errorf("got message %q, want the duplicate source listed", werr.Message)
+	}
+
+	invalid := []CreateSwitchRequestInput{
+		{AccountID: "acc_1", SwitchFromFundID: "fund_a", SwitchToFundID: "fund_b", RequestedAmount: 100},
+		{AccountID: "acc_2", SwitchFromFundID: "fund_b", SwitchToFundID: "fund_b"},
+	}
+	err = ValidateSwitchPlan(invalid)
+	if !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Fatalf("got %v, want %s", err, ErrInvalidParameter)
+	}
+	for _, want := range []string{
+		"entry 1: account acc_2 differs",
+		"entry 1: switches fund fund_b class 0 to itself",
+		"entry 1: requested amount or units must be positive",
+	} {
+		if !strings.Contains(werr.Message, want) {
+			t.Errorf("got message %q, want it to contain %q", werr.Message, want)
+		}
+	}
+}
+
+func TestListClientAccountBalanceContextDisplayCurrency(t *testing.T) {
+	var payload ListClientAccountBalanceInput
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		if err := json.Unmarshal(decodeTestRequest(t, req).Payload, &payload); err != nil {
+			t.Fatal(err)
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"balance": []any{}}), nil
+	})
+	ctx := WithDisplayCurrency(context.Background(), "USD")
+
+	input := &ListClientAccountBalanceInput{AccountID: "acc_1"}
+	if _, err := c.ListClientAccountBalance(ctx, input); err != nil {
+		t.Fatal(err)
+	}
+	if payload.DisplayCurrency != "USD" {
+		t.Errorf("got display currency %q, want the context currency USD", payload.DisplayCurrency)
+	}
+	if input.DisplayCurrency != "" {
+		t.Errorf("got input display currency %q, want the input untouched", input.DisplayCurrency)
+	}
+
+	// explicit input wins.
+	if _, err := c.ListClientAccountBalance(ctx, &ListClientAccountBalanceInput{AccountID: "acc_1", DisplayCurrency: "SGD"}); err != nil {
+		t.Fatal(err)
+	}
+	if payload.DisplayCurrency != "SGD" {
+		t.Errorf("got display currency %q, want the input currency SGD", payload.DisplayCurrency)
+	}
+
+	// a nil input is sent with the context currency.
+	if _, err := c.ListClientAccountBalance(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if payload.DisplayCurrency != "USD" {
+		t.Errorf("got display currency %q for a nil input, want the context currency USD", payload.DisplayCurrency)
+	}
+}
+
+func TestComputeAllocationDrift(t *testing.T) {
+	current := []AllocationPerformanceSeries{
+		{AllocationID: "alloc_1", FundID: "equity", FundName: "Equity Fund", WeightPercentage: 55},
+		{AllocationID: "alloc_2", FundID: "bond", FundName: "Bond Fund", WeightPercentage: 38},
+		{AllocationID: "alloc_3", FundID: "gold", FundName: "Gold Fund", WeightPercentage: 7},
+	}
+	target := map[string]float64{"equity": 50, "bond": 40, "cash": 10}
+
+	got := ComputeAllocationDrift(current, target, 3)
+	want := []DriftEntry{
+		// overweight
+		{FundID: "equity", FundName: "Equity Fund", CurrentPercentage: 55, TargetPercentage: 50, Drift: 5, RelativeDrift: 0.1, ExceedsThreshold: true},
+		// underweight within the threshold
+		{FundID: "bond", FundName: "Bond Fund", CurrentPercentage: 38, TargetPercentage: 40, Drift: -2, RelativeDrift: -0.05},
+		// held but not targeted
+		{FundID: "gold", FundName: "Gold Fund", CurrentPercentage: 7, Drift: 7, ExceedsThreshold: true},
+		// targeted but not held
+		{FundID: "cash", TargetPercentage: 10, Drift: -10, RelativeDrift: -1, ExceedsThreshold: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAccountTypeAndExperience(t *testing.T) {
+	var account ClientAccount
+	if err := json.Unmarshal([]byte(`{"type":"joint","experience":"dim"}`), &account); err != nil {
+		t.Fatal(err)
+	}
+	if account.Type != AccountTypeJoint || !account.Type.IsKnown() || account.Type.Valid() != nil {
+		t.Errorf("got type %q, want known %q", account.Type, AccountTypeJoint)
+	}
+	if account.Experience != AccountExperienceDim || !account.Experience.IsKnown() || account.Experience.Valid() != nil {
+		t.Errorf("got experience %q, want known %q", account.Experience, AccountExperienceDim)
+	}
+
+	// unknown values are decoded as is for forward compatibility.
+	if err := json.Unmarshal([]byte(`{"type":"corporate","experience":"robo"}`), &account); err != nil {
+		t.Fatal(err)
+	}
+	if account.Type != "corporate" || account.Type.IsKnown() {
+		t.Errorf("got type %q, want unknown corporate", account.Type)
+	}
+	if account.Experience != "robo" || account.Experience.IsKnown() {
+		t.Errorf("got experience %q, want unknown robo", account.Experience)
+	}
+	var werr Error
+	if err := account.Type.Valid(); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got %v, want %s", err, ErrInvalidParameter)
+	}
+	if err := account.Experience.Valid(); !errors.As(err, &werr) || werr.Code != ErrInvalidParameter {
+		t.Errorf("got %v, want %s", err, ErrInvalidParameter)
+	}
+}
+
+func TestGetProjectedFundPrices(t *testing.T) {
+	navs := map[string]float64{"fund_a": 1.01, "fund_b": 0.98, "fund_c": 1.25, "fund_d": 2.5, "fund_e": 0.5}
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		var input GetProjectedFundPriceInput
+		if err := json.Unmarshal(decodeTestRequest(t, req).Payload, &input); err != nil {
+			t.Error(err)
+		}
+		nav, ok := navs[input.FundID]
+		if !ok {
+			return jsonResponse(http.StatusNotFound, map[string]any{"code": ErrMissingResource}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"asset": "MYR", "netAssetValuePerUnit": nav}), nil
+	})
+
+	inputs := []GetProjectedFundPriceInput{{FundID: "fund_unknown", FundClassSequence: 1}}
+	for fundID := range navs {
+		inputs = append(inputs, GetProjectedFundPriceInput{FundID: fundID, FundClassSequence: 1})
+	}
+	result := c.GetProjectedFundPrices(context.Background(), inputs)
+	prices, errs := result.Succeeded, result.Failed
+	if len(prices) != len(navs) {
+		t.Errorf("got %d prices, want %d", len(prices), len(navs))
+	}
+	for fundID, nav := range navs {
+		if prices[fundID] == nil || prices[fundID].NetAssetValuePerUnit != nav {
+			t.Errorf("got price %+v for %s, want %v", prices[fundID], fundID, nav)
+		}
+	}
+	var werr Error
+	if len(errs) != 1 || !errors.As(errs["fund_unknown"], &werr) || werr.Code != ErrMissingResource {
+		t.Errorf("got errors %v, want %s for fund_unknown", errs, ErrMissingResource)
+	}
+	if err := result.Err(); err != nil {
+		t.Errorf("got error %v on partial success, want nil", err)
+	}
+}
+
+func TestDownloadConfirmations(t *testing.T) {
+	documents := map[string]string{"req_1": "%PDF-1.7 first", "req_2": "%PDF-1.7 second"}
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		var input GetClientAccountRequestConfirmationInput
+		if err := json.Unmarshal(decodeTestRequest(t, req).Payload, &input); err != nil {
+			t.Error(err)
+		}
+		document, ok := documents[input.RequestID]
+		if !ok || input.AccountID != "acc_1" {
+			return jsonResponse(http.StatusNotFound, map[string]any{"code": ErrMissingResource}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{
+			"format":   "pdf",
+			"filename": "confirmation.pdf",
+			"bytes":    []byte(document),
+		}), nil
+	})
+
+	var archive bytes.Buffer
+	result, err := c.DownloadConfirmations(context.Background(), "acc_1", []string{"req_1", "req_missing", "req_2"}, &archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var werr Error
+	if len(result.Failed) != 1 || !errors.As(result.Failed["req_missing"], &werr) || werr.Code != ErrMissingResource {
+		t.Errorf("got errors %v, want %s for req_missing", result.Failed, ErrMissingResource)
+	}
+	if len(result.Succeeded) != 2 || result.Succeeded["req_1"] != "req_1.pdf" || result.Succeeded["req_2"] != "req_2.pdf" {
+		t.Errorf("got entries %v, want req_1.pdf and req_2.pdf", result.Succeeded)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		if want := documents[strings.TrimSuffix(f.Name, ".pdf")]; string(b) != want {
+			t.Errorf("got %s content %q, want %q", f.Name, b, want)
+		}
+	}
+	if strings.Join(names, ",") != "req_1.pdf,req_2.pdf" {
+		t.Errorf("got entries %v, want req_1.pdf and req_2.pdf", names)
+	}
+}
+
+func TestListClientAccountBalances(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		var input ListClientAccountBalanceInput
+		if err := json.Unmarshal(decodeTestRequest(t, req).Payload, &input); err != nil {
+			t.Error(err)
+		}
+		switch input.AccountID {
+		case "acc_1", "acc_2":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"balance": []map[string]any{{"fundId": "fund_" + input.AccountID}},
+			}), nil
+		case "acc_denied":
+			return jsonResponse(http.StatusForbidden, map[string]any{"code": ErrInsufficientAccess}), nil
+		}
+		return jsonResponse(http.StatusNotFound, map[string]any{"code": ErrMissingResource}), nil
+	})
+	ctx := context.Background()
+
+	result := c.ListClientAccountBalances(ctx, []ListClientAccountBalanceInput{
+		{AccountID: "acc_1"}, {AccountID: "acc_denied"}, {AccountID: "acc_2"}, {AccountID: "acc_missing"},
+	})
+	if len(result.Succeeded) != 2 || len(result.Succeeded["acc_2"].Balance) != 1 {
+		t.Errorf("got balances %v, want acc_1 and acc_2", result.Succeeded)
+	}
+	var werr Error
+	if !errors.As(result.Failed["acc_denied"], &werr) || werr.Code != ErrInsufficientAccess {
+		t.Errorf("got error %v for acc_denied, want %s", result.Failed["acc_denied"], ErrInsufficientAccess)
+	}
+	if !errors.As(result.Failed["acc_missing"], &werr) || werr.Code != ErrMissingResource {
+		t.Errorf("got error %v for acc_missing, want %s", result.Failed["acc_missing"], ErrMissingResource)
+	}
+	if err := result.Err(); err != nil {
+		t.Errorf("got error %v on partial success, want nil", err)
+	}
+
+	// every account failed.
+	result = c.ListClientAccountBalances(ctx, []ListClientAccountBalanceInput{{AccountID: "acc_denied"}, {AccountID: "acc_missing"}})
+	err := result.Err()
+	if !errors.As(err, &werr) || !strings.Contains(err.Error(), "acc_denied: ") || !strings.Contains(err.Error(), "acc_missing: ") {
+		t.Errorf("got error %v, want the errors of both accounts", err)
+	}
+	if err := (&BatchResult[int]{}).Err(); err != nil {
+		t.Errorf("got error %v for an empty batch, want nil", err)
+	}
+}
+
+func TestCommandInputValidation(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request %q", decodeTestRequest(t, req).Name)
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+	ctx := context.Background()
+	invalidEmail := "not an email"
+
+	for _, tt := range []struct {
+		name     string
+		call     func() error
+		wantCode string
+	}{
+		{"nil investment", func() error { _, err := c.CreateInvestmentRequest(ctx, nil); return err }, ErrMissingParameter},
+		{"investment without fund", func() error {
+			_, err := c.CreateInvestmentRequest(ctx, &CreateInvestmentRequestInput{AccountID: "a1", Amount: NewAmount(100, "MYR")})
+			return err
+		}, ErrMissingParameter},
+		{"negative investment", func() error {
+			_, err := c.CreateInvestmentRequest(ctx, &CreateInvestmentRequestInput{AccountID: "a1", FundID: "f1", Amount: NewAmount(-1, "MYR")})
+			return err
+		}, ErrInvalidParameter},
+		{"empty redemption", func() error {
+			_, err := c.CreateRedemptionRequest(ctx, &CreateRedemptionRequestInput{AccountID: "a1", FundID: "f1"})
+			return err
+		}, ErrInvalidParameter},
+		{"switch without target", func() error {
+			_, err := c.CreateSwitchRequest(ctx, &CreateSwitchRequestInput{AccountID: "a1", SwitchFromFundID: "f1", Units: 1})
+			return err
+		}, ErrMissingParameter},
+		{"cancellation without request", func() error {
+			_, err := c.CreateRequestCancellation(ctx, &CreateRequestCancellationInput{})
+			return err
+		}, ErrMissingParameter},
+		{"bank account without account", func() error {
+			_, err := c.CreateClientBankAccount(ctx, &CreateClientBankAccountInput{})
+			return err
+		}, ErrMissingParameter},
+		{"display currency without currency", func() error {
+			_, err := c.UpdateDisplayCurrency(ctx, &UpdateDisplayCurrencyInput{})
+			return err
+		}, ErrMissingParameter},
+		{"profile with invalid email", func() error {
+			_, err := c.UpdateClientProfile(ctx, &UpdateClientProfileInput{Email: &invalidEmail})
+			return err
+		}, ErrInvalidParameter},
+		{"profile with other ethnicity", func() error {
+			_, err := c.UpdateClientProfile(ctx, &UpdateClientProfileInput{Ethnicity: "other"})
+			return err
+		}, ErrMissingParameter},
+		{"duitnow payment without amount", func() error {
+			_, err := c.CreateDuitnowPayment(ctx, &CreateDuitnowPaymentInput{AccountID: "a1"})
+			return err
+		}, ErrInvalidParameter},
+	} {
+		var werr Error
+		if err := tt.call(); !errors.As(err, &werr) || werr.Code != tt.wantCode {
+			t.Errorf("%s: got %v, want %s", tt.name, err, tt.wantCode)
+		}
+	}
+}
+
+func TestListOutputsPagination(t *testing.T) {
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		switch decodeTestRequest(t, req).Name {
+		case "list_client_accounts":
+			return jsonResponse(http.StatusOK, map[string]any{
+				"accounts":   []map[string]any{{"id": "acc_1"}},
+				"nextCursor": "cur_2",
+				"hasMore":    true,
+				"total":      3,
+			}), nil
+		default:
+			return jsonResponse(http.StatusOK, map[string]any{"requests": []map[string]any{{"id": "req_1"}}, "total": 1}), nil
+		}
+	})
+	ctx := context.Background()
+
+	accounts, err := c.ListClientAccounts(ctx, &ListClientAccountsInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Pagination{NextCursor: "cur_2", HasMore: true, Total: 3}); accounts.Pagination != want || len(accounts.Accounts) != 1 {
+		t.Errorf("got pagination %+v and %d accounts, want %+v", accounts.Pagination, len(accounts.Accounts), want)
+	}
+
+	requests, err := c.ListClientAccountRequests(ctx, &ListClientAccountRequestsInput{AccountID: "acc_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Pagination{Total: 1}); requests.Pagination != want {
+		t.Errorf("got pagination %+v, want %+v", requests.Pagination, want)
+	}
+}
+
+func TestListClientAccountsExperiences(t *testing.T) {
+	var payload string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payload = string(decodeTestRequest(t, req).Payload)
+		return jsonResponse(http.StatusOK, map[string]any{"accounts": []map[string]any{{"id": "acc_1", "experience": "dim"}}}), nil
+	})
+	_, err := c.ListClientAccounts(context.Background(), &ListClientAccountsInput{
+		Experiences: []AccountExperience{AccountExperienceDim, AccountExperienceFundManagement},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"experiences":["dim","fundmanagement"]}`; payload != want {
+		t.Errorf("got payload %s, want %s", payload, want)
+	}
+}
+
+func TestFilterByExperience(t *testing.T) {
+	accounts := []ClientAccount{
+		{ID: "acc_1", Experience: AccountExperienceDim},
+		{ID: "acc_2", Experience: AccountExperienceFundManagement},
+		{ID: "acc_3", Experience: AccountExperienceDim},
+	}
+	var ids []string
+	for _, account := range FilterByExperience(accounts, AccountExperienceDim) {
+		ids = append(ids, account.ID)
+	}
+	if got := strings.Join(ids, ","); got != "acc_1,acc_3" {
+		t.Errorf("got accounts %s, want acc_1,acc_3", got)
+	}
+	if got := FilterByExperience(accounts, AccountExperienceMandate); len(got) != 0 {
+		t.Errorf("got accounts %+v, want none", got)
+	}
+}
+
+func TestListClientBankAccountsFilters(t *testing.T) {
+	var payloads []string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		payloads = append(payloads, string(decodeTestRequest(t, req).Payload))
+		if len(payloads) == 1 {
+			return jsonResponse(http.StatusOK, map[string]any{
+				"bankAccounts": []map[string]any{{"accountNumber": "1"}},
+				"nextCursor":   "cur_2",
+				"hasMore":      true,
+				"total":        2,
+			}), nil
+		}
+		return jsonResponse(http.StatusOK, map[string]any{"bankAccounts": []map[string]any{{"accountNumber": "2"}}, "total": 2}), nil
+	})
+	ctx := context.Background()
+
+	limit := 1
+	input := &ListClientBankAccountsInput{BankBics: []string{"MBBEMYKL"}, Statuses: []string{BankAccountStatusVerified}, Limit: &limit}
+	var numbers []string
+	for {
+		output, err := c.ListClientBankAccounts(ctx, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, account := range output.BankAccounts {
+			numbers = append(numbers, account.AccountNumber)
+		}
+		if !output.HasMore {
+			break
+		}
+		input.Cursor = &output.NextCursor
+	}
+	if got := strings.Join(numbers, ","); got != "1,2" {
+		t.Errorf("got bank accounts %s, want 1,2", got)
+	}
+	want := []string{
+		`{"bankBics":["MBBEMYKL"],"statuses":["verified"],"limit":1}`,
+		`{"bankBics":["MBBEMYKL"],"statuses":["verified"],"limit":1,"cursor":"cur_2"}`,
+	}
+	if strings.Join(payloads, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got payloads\n%s\nwant\n%s", strings.Join(payloads, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPayoutBankAccounts(t *testing.T) {
+	accounts := []BankAccount{
+		{AccountNumber: "1", Status: BankAccountStatusVerified},
+		{AccountNumber: "2", Status: BankAccountStatusPending},
+		{AccountNumber: "3", Status: BankAccountStatusRejected},
+		{AccountNumber: "4", Status: BankAccountStatusVerified},
+	}
+	var numbers []string
+	for _, account := range PayoutBankAccounts(accounts) {
+		numbers = append(numbers, account.AccountNumber)
+	}
+	if got := strings.Join(numbers, ","); got != "1,4" {
+		t.Errorf("got bank accounts %s, want 1,4", got)
+	}
+}
+
+func TestVerifyDuitNowSettlement(t *testing.T) {
+	request := map[string]any{"id": "req_1", "amount": 250.5, "asset": "MYR", "status": "completed", "duitnowEndToEndId": "e2e_1"}
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		var input GetRequestByDuitNowEndToEndIDInput
+		if err := json.Unmarshal(decodeTestRequest(t, req).Payload, &input); err != nil {
+			t.Fatal(err)
+		}
+		switch input.EndToEndID {
+		case "e2e_1":
+			return jsonResponse(http.StatusOK, map[string]any{"request": request}), nil
+		case "e2e_pending":
+			return jsonResponse(http.StatusOK, map[string]any{"request": map[string]any{"id": "req_2", "amount": 250.5, "asset": "MYR", "status": "pending"}}), nil
+		}
+		return jsonResponse(http.StatusNotFound, map[string]any{"code": ErrMissingResource}), nil
+	})
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name        string
+		endToEndID  string
+		expected    Amount
+		wantSettled bool
+		wantRequest string
+		wantCode    string
+	}{
+		{"match", "e2e_1", NewAmount(250.5, "MYR"), true, "req_1", ""},
+		{"pending", "e2e_pending", NewAmount(250.5, "MYR"), false, "req_2", ""},
+		{"amount mismatch", "e2e_1", NewAmount(250, "MYR"), false, "req_1", ErrSettlementMismatch},
+		{"currency mismatch", "e2e_1", NewAmount(250.5, "USD"), false, "req_1", ErrSettlementMismatch},
+		{"not found", "e2e_unknown", NewAmount(250.5, "MYR"), false, "", ErrMissingResource},
+	} {
+		settled, got, err := c.VerifyDuitNowSettlement(ctx, tt.endToEndID, tt.expected)
+		var werr Error
+		if tt.wantCode == "" && err != nil || tt.wantCode != "" && (!errors.As(err, &werr) || werr.Code != tt.wantCode) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.wantCode)
+		}
+		if settled != tt.wantSettled {
+			t.Errorf("%s: got settled %t, want %t", tt.name, settled, tt.wantSettled)
+		}
+		id := ""
+		if got != nil {
+			id = got.ID
+		}
+		if id != tt.wantRequest {
+			t.Errorf("%s: got request %q, want %q", tt.name, id, tt.wantRequest)
+		}
+	}
+}
+
+func TestRecomputeExposure(t *testing.T) {
+	accounts := []ClientAccount{
+		{ID: "acc_1", PortfolioValue: 300, ExposurePercentage: 30},
+		{ID: "acc_2", PortfolioValue: 100, ExposurePercentage: 10},
+	}
+	got := RecomputeExposure(accounts)
+	if len(got) != 2 || got[0].ExposurePercentage != 75 || got[1].ExposurePercentage != 25 {
+		t.Errorf("got %+v, want exposures 75 and 25", got)
+	}
+	if accounts[0].ExposurePercentage != 30 {
+		t.Error("the accounts given were modified")
+	}
+
+	got = RecomputeExposure([]ClientAccount{{ID: "acc_1", ExposurePercentage: 50}, {ID: "acc_2"}})
+	if len(got) != 2 || got[0].ExposurePercentage != 0 || got[1].ExposurePercentage != 0 {
+		t.Errorf("got %+v, want zero exposures", got)
+	}
+	if got := RecomputeExposure(nil); len(got) != 0 {
+		t.Errorf("got %+v, want none", got)
+	}
+}
+
+func TestNilInput(t *testing.T) {
+	sent := 0
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		sent++
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+	ctx := context.Background()
+	for name, call := range map[string]func() error{
+		"GetClientAccountAllocationPerformance": func() error {
+			_, err := c.GetClientAccountAllocationPerformance(ctx, nil)
+			return err
+		},
+		"WriteClientAccountStatementCSV": func() error {
+			_, err := c.WriteClientAccountStatementCSV(ctx, nil, io.Discard)
+			return err
+		},
+		"StreamClientAccountStatement": func() error {
+			return c.StreamClientAccountStatement(ctx, nil, func(tx StatementTransaction) error { return nil })
+		},
+		"GetRequestByDuitNowEndToEndID": func() error {
+			_, err := c.GetRequestByDuitNowEndToEndID(ctx, nil)
+			return err
+		},
+		"GetGoalProjection": func() error {
+			_, err := c.GetGoalProjection(ctx, nil)
+			return err
+		},
+		"GetJointInvitationStatus": func() error {
+			_, err := c.GetJointInvitationStatus(ctx, nil)
+			return err
+		},
+	} {
+		var werr Error
+		if err := call(); !errors.As(err, &werr) || werr.Code != ErrMissingParameter {
+			t.Errorf("%s: got %v, want %s", name, err, ErrMissingParameter)
+		}
+	}
+	if sent != 0 {
+		t.Errorf("sent %d requests, want none", sent)
+	}
+}
+
+func TestNilOptionalInput(t *testing.T) {
+	var names []string
+	c := newTestClient(t, nil, func(req *http.Request) (*http.Response, error) {
+		names = append(names, decodeTestRequest(t, req).Name)
+		return jsonResponse(http.StatusOK, map[string]any{}), nil
+	})
+	ctx := context.Background()
+	// the inputs of the queries filtering optionally can be nil.
+	for _, call := range []func() error{
+		func() error {
+			_, err := c.ListClientAccountRequests(ctx, nil)
+			return err
+		},
+		func() error {
+			_, err := c.ListClientAccountPerformance(ctx, nil)
+			return err
+		},
+		func() error {
+			_, err := c.ListDuitNowBanks(ctx, nil)
+			return err
+		},
+	} {
+		if err := call(); err != nil {
+			t.Error(err)
+		}
+	}
+	want := []string{"list_client_account_requests", "list_client_account_performance", "list_duitnow_banks"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("got requests %v, want %v", names, want)
+	}
+}